@@ -1,34 +1,39 @@
 package T
 
 import (
+	"net"
 	"time"
 )
 
-var Int int
-var IntList []int
-var Float float64
-var FloatList []float64
-var Duration time.Duration
-var DurationList []time.Duration
-var Time time.Time
-var TimeList []time.Time
-var Date time.Time
-var DateList []time.Time
-var Size int
-var SizeList []int
-var String string
-var StringList []string
-var Url string
-var UrlList []string
-var Address string
-var AddressList []string
-var Base58 []byte
-var Base58List [][]byte
-var Base32 []byte
-var Base32List [][]byte
-var Hex string
-var HexList []string
-var Key struct {
+type Int int
+type IntList []int
+type Float float64
+type FloatList []float64
+type Duration time.Duration
+type DurationList []time.Duration
+type Time time.Time
+type TimeList []time.Time
+type Date time.Time
+type DateList []time.Time
+type Size int
+type SizeList []int
+type String string
+type StringList []string
+type Url string
+type UrlList []string
+type Address string
+type AddressList []string
+type Base58 []byte
+type Base58List [][]byte
+type Base32 []byte
+type Base32List [][]byte
+type Hex string
+type HexList []string
+type IPNet *net.IPNet
+type IPNetList []*net.IPNet
+type Port uint16
+type PortRange [2]uint16
+type Key struct {
 	Label    string
 	Template interface{}
 }
@@ -116,6 +121,10 @@ var (
 	BASE32LIST   = addType("base32list", *new(Base32))
 	HEX          = addType("hex", *new(Hex))
 	HEXLIST      = addType("hexlist", *new(HexList))
+	IPNET        = addType("ipnet", *new(IPNet))
+	IPNETLIST    = addType("ipnetlist", *new(IPNetList))
+	PORT         = addType("port", *new(Port))
+	PORTRANGE    = addType("portrange", *new(PortRange))
 	HelpTypes    = []string{"pre", "markdown", "html"}
 )
 
@@ -0,0 +1,151 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Validity is how long generated certificates last
+const Validity = 10 * 365 * 24 * time.Hour
+
+// Config is the TLS parameter bundle of a daemon
+type Config struct {
+	Enable   bool
+	Cert     string
+	Key      string
+	ClientCA string
+}
+
+// Ensure generates a self-signed certificate and key at the configured paths when TLS is enabled and neither file
+// exists yet. Only one of the two existing is an error, as regenerating would orphan the other
+func Ensure(c Config, org string) error {
+	if !c.Enable {
+		return nil
+	}
+	certExists, keyExists := exists(c.Cert), exists(c.Key)
+	switch {
+	case certExists && keyExists:
+		return nil
+	case certExists || keyExists:
+		return errors.New("only one of " + c.Cert + " and " + c.Key + " exists; remove it to generate a new pair")
+	}
+	return Generate(c.Cert, c.Key, org, nil)
+}
+
+// Generate writes a new self-signed certificate and key, valid for localhost, the host's name and addresses, and
+// any extra hosts given
+func Generate(certFile, keyFile, org string, extraHosts []string) (err error) {
+	var key *ecdsa.PrivateKey
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return
+	}
+	var serial *big.Int
+	if serial, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return
+	}
+	host, _ := os.Hostname()
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{org}, CommonName: host},
+		NotBefore:    now.Add(-24 * time.Hour),
+		NotAfter:     now.Add(Validity),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	for _, h := range append([]string{"localhost", host}, extraHosts...) {
+		addHost(tmpl, h)
+	}
+	for _, ip := range []string{"127.0.0.1", "::1"} {
+		addHost(tmpl, ip)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			if ipn, ok := a.(*net.IPNet); ok {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ipn.IP)
+			}
+		}
+	}
+	var der []byte
+	if der, err = x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key); err != nil {
+		return
+	}
+	var keyDER []byte
+	if keyDER, err = x509.MarshalECPrivateKey(key); err != nil {
+		return
+	}
+	if err = write(certFile, "CERTIFICATE", der, 0644); err != nil {
+		return
+	}
+	if err = write(keyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		os.Remove(certFile)
+	}
+	return
+}
+
+// TLSConfig loads the certificate pair and, if a client CA is configured, requires clients to present a
+// certificate signed by it. It returns nil when TLS is disabled
+func TLSConfig(c Config) (tc *tls.Config, err error) {
+	if !c.Enable {
+		return
+	}
+	var pair tls.Certificate
+	if pair, err = tls.LoadX509KeyPair(c.Cert, c.Key); err != nil {
+		return
+	}
+	tc = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCA == "" {
+		return
+	}
+	var ca []byte
+	if ca, err = ioutil.ReadFile(c.ClientCA); err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no certificates found in " + c.ClientCA)
+	}
+	tc.ClientCAs, tc.ClientAuth = pool, tls.RequireAndVerifyClientCert
+	return
+}
+
+func addHost(c *x509.Certificate, h string) {
+	if h == "" {
+		return
+	}
+	if ip := net.ParseIP(h); ip != nil {
+		c.IPAddresses = append(c.IPAddresses, ip)
+		return
+	}
+	c.DNSNames = append(c.DNSNames, h)
+}
+
+func write(path, kind string, der []byte, mode os.FileMode) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	return ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), mode)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,22 @@
+package conf
+
+import "os"
+
+// FirstRun returns true when neither the config file nor the data directory exists yet
+func FirstRun(confFile, dataDir string) bool {
+	return !exists(confFile) && !exists(dataDir)
+}
+
+// Bootstrap runs fn, such as conf init followed by a setup wizard, if this is the first run. Automation passes
+// skip, from --no-bootstrap, to never run it
+func Bootstrap(confFile, dataDir string, skip bool, fn func() error) error {
+	if skip || fn == nil || !FirstRun(confFile, dataDir) {
+		return nil
+	}
+	return fn()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err)
+}
@@ -0,0 +1,174 @@
+package conf
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/l0k1verloren/skele/pkg/fail"
+)
+
+// IncludeKey is the key of a directive that reads another file in place
+const IncludeKey = "include"
+
+// OverlayDir is the directory beside the config file whose .conf files are read after it
+const OverlayDir = "conf.d"
+
+// Entry is one key = value line read from a config file
+type Entry struct {
+	Key   string
+	Value string
+	File  string
+	Line  int
+}
+
+// String shows where the entry came from, for error messages
+func (e Entry) String() string {
+	return fmt.Sprintf("%s:%d: %s = %s", e.File, e.Line, e.Key, e.Value)
+}
+
+// Load reads the config file at path, expanding include directives where they appear, then the .conf files
+// in the conf.d directory beside it in lexical order. Entries are returned in the order read, so later ones
+// override earlier ones. A missing conf.d is not an error
+func Load(path string) (out []Entry, err error) {
+	return LoadEncrypted(path, nil)
+}
+
+// LoadEncrypted is Load for configs where some files, or some values, are encrypted with the passphrase
+func LoadEncrypted(path string, passphrase []byte) (out []Entry, err error) {
+	return loadAll(path, passphrase, nil)
+}
+
+// loadAll is LoadEncrypted, adding the name of every file read to files if it is not nil
+func loadAll(path string, passphrase []byte, files *[]string) (out []Entry, err error) {
+	if out, err = load(path, passphrase, nil, files); err != nil {
+		return
+	}
+	var e []Entry
+	if e, err = overlay(path, passphrase, files); err != nil {
+		return nil, err
+	}
+	return append(out, e...), nil
+}
+
+// overlay reads the .conf files in the conf.d directory beside path
+func overlay(path string, passphrase []byte, files *[]string) (out []Entry, err error) {
+	var names []string
+	if names, err = filepath.Glob(filepath.Join(filepath.Dir(path), OverlayDir, "*.conf")); err != nil {
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var e []Entry
+		if e, err = load(name, passphrase, nil, files); err != nil {
+			return
+		}
+		out = append(out, e...)
+	}
+	return
+}
+
+// Values flattens entries into a map where the last entry for each key wins
+func Values(entries []Entry) map[string]string {
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		out[e.Key] = e.Value
+	}
+	return out
+}
+
+// load reads one file, recursing into includes. stack holds the files currently being read, to detect cycles, and
+// the name of every file read is added to files if it is not nil
+func load(path string, passphrase []byte, stack []string, files *[]string) (out []Entry, err error) {
+	if path, err = filepath.Abs(path); err != nil {
+		return
+	}
+	for i, s := range stack {
+		if s == path {
+			return nil, fmt.Errorf("include cycle: %s -> %s",
+				strings.Join(stack[i:], " -> "), path)
+		}
+	}
+	stack = append(stack, path)
+	if files != nil {
+		*files = append(*files, path)
+	}
+	var b []byte
+	if b, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	var entries []Entry
+	if entries, err = decode(path, b, passphrase); err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.Key != IncludeKey {
+			out = append(out, e)
+			continue
+		}
+		inc := e.Value
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		var sub []Entry
+		if sub, err = load(inc, passphrase, stack, files); err != nil {
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("%s:%d: %v", e.File, e.Line, err)
+			}
+			return
+		}
+		out = append(out, sub...)
+	}
+	return
+}
+
+// decode parses the contents of the file named name, decrypting it if it is encrypted and opening its enc: values
+func decode(name string, b, passphrase []byte) (out []Entry, err error) {
+	if Encrypted(b) {
+		if b, err = Decrypt(b, passphrase); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	if out, err = Parse(name, string(b)); err != nil {
+		return
+	}
+	for i, e := range out {
+		if out[i].Value, err = OpenValue(e.Value, passphrase); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", e.File, e.Line, err)
+		}
+	}
+	return
+}
+
+// Parse reads key = value lines from src. Blank lines and lines starting with # or ; are ignored.
+// file is only used to label the entries and errors. Every malformed line is reported, in a fail.Multi
+func Parse(file, src string) (out []Entry, err error) {
+	var problems fail.Multi
+	s := bufio.NewScanner(strings.NewReader(src))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 1 {
+			problems.Add(fmt.Errorf("%s:%d: expected key = value", file, n))
+			continue
+		}
+		out = append(out, Entry{
+			Key:   strings.TrimSpace(line[:i]),
+			Value: strings.TrimSpace(line[i+1:]),
+			File:  file,
+			Line:  n,
+		})
+	}
+	problems.Add(s.Err())
+	if err = problems.Err(); err != nil {
+		return nil, err
+	}
+	return
+}
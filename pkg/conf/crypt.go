@@ -0,0 +1,160 @@
+package conf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Magic starts every encrypted config file
+const Magic = "skele-aesgcm-1\n"
+
+// SecretPrefix marks a value that is encrypted on its own inside a plain text config file
+const SecretPrefix = "enc:"
+
+const saltLen = 16
+
+// ErrNoKey is returned when an encrypted file or value is read without a passphrase
+var ErrNoKey = errors.New("config is encrypted and no passphrase was given")
+
+// KeyFile reads a passphrase from a file, ignoring surrounding whitespace
+func KeyFile(path string) (key []byte, err error) {
+	var b []byte
+	if b, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	return bytes.TrimSpace(b), nil
+}
+
+// PromptPassphrase asks for a passphrase on the terminal without echoing it, showing prompt on stderr. With confirm
+// set it is asked for twice and must match, as when a file is first encrypted. It fails if stdin is not a terminal
+func PromptPassphrase(prompt string, confirm bool) (key []byte, err error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, errors.New("cannot prompt for a passphrase: stdin is not a terminal")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	key, err = terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil || !confirm {
+		return
+	}
+	fmt.Fprint(os.Stderr, "again: ")
+	var again []byte
+	again, err = terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(key, again) {
+		return nil, errors.New("passphrases do not match")
+	}
+	return
+}
+
+// Encrypted returns true if the file contents start with Magic
+func Encrypted(b []byte) bool {
+	return bytes.HasPrefix(b, []byte(Magic))
+}
+
+// Encrypt seals a whole config file with a key derived from the passphrase
+func Encrypt(plain, passphrase []byte) (out []byte, err error) {
+	if out, err = seal(plain, passphrase); err != nil {
+		return
+	}
+	return append([]byte(Magic), out...), nil
+}
+
+// Decrypt opens a config file sealed by Encrypt
+func Decrypt(b, passphrase []byte) ([]byte, error) {
+	if !Encrypted(b) {
+		return nil, errors.New("config is not encrypted")
+	}
+	return open(b[len(Magic):], passphrase)
+}
+
+// SealValue encrypts a single value, such as a password, for storing in a plain text config file
+func SealValue(value string, passphrase []byte) (out string, err error) {
+	var b []byte
+	if b, err = seal([]byte(value), passphrase); err != nil {
+		return
+	}
+	return SecretPrefix + base64.StdEncoding.EncodeToString(b), nil
+}
+
+// OpenValue decrypts a value sealed by SealValue. Values without SecretPrefix are returned unchanged
+func OpenValue(value string, passphrase []byte) (out string, err error) {
+	if !strings.HasPrefix(value, SecretPrefix) {
+		return value, nil
+	}
+	var b []byte
+	if b, err = base64.StdEncoding.DecodeString(value[len(SecretPrefix):]); err != nil {
+		return
+	}
+	if b, err = open(b, passphrase); err != nil {
+		return
+	}
+	return string(b), nil
+}
+
+// seal produces salt | nonce | ciphertext
+func seal(plain, passphrase []byte) (out []byte, err error) {
+	salt := make([]byte, saltLen)
+	if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+		return
+	}
+	var aead cipher.AEAD
+	if aead, err = newAEAD(passphrase, salt); err != nil {
+		return
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+	out = append(salt, nonce...)
+	return aead.Seal(out, nonce, plain, nil), nil
+}
+
+func open(b, passphrase []byte) (out []byte, err error) {
+	if len(b) < saltLen {
+		return nil, errors.New("encrypted config is truncated")
+	}
+	var aead cipher.AEAD
+	if aead, err = newAEAD(passphrase, b[:saltLen]); err != nil {
+		return
+	}
+	b = b[saltLen:]
+	if len(b) < aead.NonceSize() {
+		return nil, errors.New("encrypted config is truncated")
+	}
+	if out, err = aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil); err != nil {
+		err = errors.New("cannot decrypt config: wrong passphrase or corrupted data")
+	}
+	return
+}
+
+func newAEAD(passphrase, salt []byte) (aead cipher.AEAD, err error) {
+	if len(passphrase) == 0 {
+		return nil, ErrNoKey
+	}
+	var key []byte
+	if key, err = scrypt.Key(passphrase, salt, 1<<15, 8, 1, 32); err != nil {
+		return
+	}
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return
+	}
+	return cipher.NewGCM(block)
+}
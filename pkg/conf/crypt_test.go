@@ -0,0 +1,77 @@
+package conf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSealOpenValue(t *testing.T) {
+	sealed, err := SealValue("hunter2", []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, SecretPrefix) || strings.Contains(sealed, "hunter2") {
+		t.Fatalf("sealed value %q is not sealed", sealed)
+	}
+	var out string
+	if out, err = OpenValue(sealed, []byte("pass")); err != nil {
+		t.Fatal(err)
+	}
+	if out != "hunter2" {
+		t.Fatalf("got %q, want hunter2", out)
+	}
+	if out, err = OpenValue("plain", nil); err != nil || out != "plain" {
+		t.Fatalf("plain value gave %q, %v", out, err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	plain := []byte("rpcuser = bob\nrpcpass = hunter2\n")
+	b, err := Encrypt(plain, []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Encrypted(b) || bytes.Contains(b, []byte("hunter2")) {
+		t.Fatal("file is not encrypted")
+	}
+	var out []byte
+	if out, err = Decrypt(b, []byte("pass")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, plain) {
+		t.Fatalf("got %q, want %q", out, plain)
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	sealed, err := SealValue("hunter2", []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = OpenValue(sealed, []byte("wrong")); err == nil {
+		t.Fatal("opened with the wrong passphrase")
+	}
+	if _, err = OpenValue(sealed, nil); err != ErrNoKey {
+		t.Fatalf("got %v, want ErrNoKey", err)
+	}
+	b, _ := Encrypt([]byte("a = 1\n"), []byte("pass"))
+	if _, err = Decrypt(b, []byte("wrong")); err == nil {
+		t.Fatal("decrypted with the wrong passphrase")
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	b, err := Encrypt([]byte("a = 1\n"), []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{len(Magic), len(Magic) + saltLen - 1, len(Magic) + saltLen + 4, len(b) - 1} {
+		if _, err = Decrypt(b[:n], []byte("pass")); err == nil {
+			t.Errorf("decrypted input truncated to %d bytes", n)
+		}
+	}
+	if _, err = OpenValue(SecretPrefix+"AAAA", []byte("pass")); err == nil {
+		t.Error("opened a truncated value")
+	}
+}
@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/l0k1verloren/skele/pkg/fail"
+)
+
+// Default is a value given to a key that was not set, chosen by the value of another key, such as a port that
+// depends on the network selected
+type Default struct {
+	Key     string
+	From    string
+	Choices map[string]string
+}
+
+// DefaultFrom declares that key defaults to choices[values[from]]. The "" entry of choices is used when from is
+// not set or has no entry of its own. A key whose choice cannot be found is left unset
+func DefaultFrom(key, from string, choices map[string]string) Default {
+	return Default{key, from, choices}
+}
+
+// ApplyDefaults fills in keys missing from values. A default may depend on a key that is itself defaulted, so they
+// are resolved in dependency order. A key with more than one default and a cycle among them are errors, and every
+// one found is reported, in a fail.Multi, after the defaults that could be resolved have been applied
+func ApplyDefaults(values map[string]string, defaults ...Default) error {
+	var problems fail.Multi
+	byKey := make(map[string]Default, len(defaults))
+	for _, d := range defaults {
+		if _, ok := byKey[d.Key]; ok {
+			problems.Add(fmt.Errorf("more than one default declared for '%s'", d.Key))
+			continue
+		}
+		byKey[d.Key] = d
+	}
+	// done holds true for keys that were resolved and false for those that failed
+	done := make(map[string]bool, len(defaults))
+	var resolve func(key string, stack []string) bool
+	resolve = func(key string, stack []string) bool {
+		d, ok := byKey[key]
+		if !ok {
+			return true
+		}
+		if ok, seen := done[key]; seen {
+			return ok
+		}
+		for _, s := range stack {
+			if s == key {
+				problems.Add(fmt.Errorf("defaults depend on each other: %s -> %s", strings.Join(stack, " -> "), key))
+				return false
+			}
+		}
+		if !resolve(d.From, append(stack, key)) {
+			done[key] = false
+			return false
+		}
+		done[key] = true
+		if _, set := values[key]; set {
+			return true
+		}
+		if v, ok := d.Choices[values[d.From]]; ok {
+			values[key] = v
+		} else if v, ok := d.Choices[""]; ok {
+			values[key] = v
+		}
+		return true
+	}
+	for _, d := range defaults {
+		resolve(d.Key, nil)
+	}
+	return problems.Err()
+}
@@ -0,0 +1,84 @@
+package conf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/l0k1verloren/skele/pkg/fail"
+)
+
+// Expand replaces ${key} references inside values with the value of that key, after all sources have been merged,
+// so derived settings like logdir = ${datadir}/logs follow the key they refer to. References may be nested through
+// several keys. $$ stands for a literal $. A reference to a missing key or a cycle of references is an error, and
+// every one found is reported, in a fail.Multi. Keys that depend on one that failed are left as they were
+func Expand(values map[string]string) error {
+	const (
+		pending = iota
+		active
+		resolved
+		failed
+	)
+	var problems fail.Multi
+	state := make(map[string]int, len(values))
+	// resolve expands one key, returning false if it or a key it refers to could not be expanded
+	var resolve func(key string, stack []string) bool
+	resolve = func(key string, stack []string) bool {
+		switch state[key] {
+		case resolved:
+			return true
+		case failed:
+			return false
+		case active:
+			problems.Add(fmt.Errorf("values refer to each other: %s -> %s", strings.Join(stack, " -> "), key))
+			return false
+		}
+		state[key] = active
+		stack = append(stack, key)
+		in := values[key]
+		var b strings.Builder
+		for i := 0; i < len(in); i++ {
+			if in[i] != '$' || i+1 == len(in) {
+				b.WriteByte(in[i])
+				continue
+			}
+			switch in[i+1] {
+			case '$':
+				b.WriteByte('$')
+				i++
+			case '{':
+				end := strings.IndexByte(in[i:], '}')
+				if end < 0 {
+					problems.Add(fmt.Errorf("%s: unterminated reference in '%s'", key, in))
+					state[key] = failed
+					return false
+				}
+				ref := in[i+2 : i+end]
+				if _, ok := values[ref]; !ok {
+					problems.Add(fmt.Errorf("%s: refers to '%s' which is not set", key, ref))
+					state[key] = failed
+					return false
+				}
+				if !resolve(ref, stack) {
+					state[key] = failed
+					return false
+				}
+				b.WriteString(values[ref])
+				i += end
+			default:
+				b.WriteByte('$')
+			}
+		}
+		values[key], state[key] = b.String(), resolved
+		return true
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		resolve(key, nil)
+	}
+	return problems.Err()
+}
@@ -0,0 +1,88 @@
+package conf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/l0k1verloren/skele/pkg/fail"
+)
+
+// Rule describes a key that may appear in a config file
+type Rule struct {
+	Key string
+	// Deprecated, if set, is reported for any use of the key, and should say what to use instead
+	Deprecated string
+	// Check, if set, returns an error for values that cannot be used, such as ones out of bounds
+	Check func(value string) error
+	// Conflicts lists keys that cannot be set together with this one
+	Conflicts []string
+}
+
+// Problem is something conf lint found, located at the entry that caused it
+type Problem struct {
+	Entry   Entry
+	Message string
+}
+
+// String formats the problem as file:line: key: message
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", p.Entry.File, p.Entry.Line, p.Entry.Key, p.Message)
+}
+
+// Error makes a Problem usable as an error
+func (p Problem) Error() string {
+	return p.String()
+}
+
+// LintError returns the problems as a single validation error listing all of them, or nil if there are none
+func LintError(problems []Problem) error {
+	var m fail.Multi
+	for _, p := range problems {
+		m.Add(p)
+	}
+	return fail.Validation(m.Err())
+}
+
+// Lint checks loaded entries against the rules without acting on any of them, and returns every problem found
+// in file order: unknown keys, deprecated keys, values their check rejects, and keys set alongside ones they
+// conflict with
+func Lint(entries []Entry, rules []Rule) (out []Problem) {
+	byKey := make(map[string]Rule, len(rules))
+	for _, r := range rules {
+		byKey[r.Key] = r
+	}
+	last := make(map[string]Entry, len(entries))
+	order := make(map[Entry]int, len(entries))
+	for i, e := range entries {
+		last[e.Key], order[e] = e, i
+		r, ok := byKey[e.Key]
+		if !ok {
+			out = append(out, Problem{e, "unknown key"})
+			continue
+		}
+		if r.Deprecated != "" {
+			out = append(out, Problem{e, "deprecated: " + r.Deprecated})
+		}
+		if r.Check != nil {
+			if err := r.Check(e.Value); err != nil {
+				out = append(out, Problem{e, err.Error()})
+			}
+		}
+	}
+	for _, r := range rules {
+		e, ok := last[r.Key]
+		if !ok {
+			continue
+		}
+		for _, c := range r.Conflicts {
+			if other, ok := last[c]; ok {
+				out = append(out, Problem{e, fmt.Sprintf("conflicts with %s set at %s:%d",
+					c, other.File, other.Line)})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return order[out[i].Entry] < order[out[j].Entry]
+	})
+	return
+}
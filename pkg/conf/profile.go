@@ -0,0 +1,82 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProfileDir is the directory beside the config file holding one <name>.conf per profile. Profiles are kept apart
+// from conf.d because every file there is always read
+const ProfileDir = "profiles"
+
+// ProfilePath returns the file holding the named profile of the config at path
+func ProfilePath(path, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\.`) {
+		return "", errors.New("invalid profile name '" + name + "'")
+	}
+	return filepath.Join(filepath.Dir(path), ProfileDir, name+".conf"), nil
+}
+
+// LoadProfile loads the config at path as LoadEncrypted does, then the named profile on top of it, so a profile
+// such as testnet replaces just the values it sets. An empty name loads no profile
+func LoadProfile(path, name string, passphrase []byte) (out []Entry, err error) {
+	if out, err = LoadEncrypted(path, passphrase); err != nil || name == "" {
+		return
+	}
+	var p string
+	if p, err = ProfilePath(path, name); err != nil {
+		return
+	}
+	var e []Entry
+	if e, err = load(p, passphrase, nil, nil); err != nil {
+		if os.IsNotExist(err) {
+			err = errors.New("no profile named '" + name + "'")
+		}
+		return nil, err
+	}
+	return append(out, e...), nil
+}
+
+// Profiles lists the names of the profiles of the config at path
+func Profiles(path string) (out []string, err error) {
+	var files []string
+	if files, err = filepath.Glob(filepath.Join(filepath.Dir(path), ProfileDir, "*.conf")); err != nil {
+		return
+	}
+	for _, f := range files {
+		out = append(out, strings.TrimSuffix(filepath.Base(f), ".conf"))
+	}
+	sort.Strings(out)
+	return
+}
+
+// CreateProfile writes a new profile with the given values, failing if it already exists
+func CreateProfile(path, name string, values map[string]string) (err error) {
+	var p string
+	if p, err = ProfilePath(path, name); err != nil {
+		return
+	}
+	if _, err = os.Stat(p); err == nil {
+		return errors.New("profile '" + name + "' already exists")
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return
+	}
+	_, err = Save(p, values, nil)
+	return
+}
+
+// DeleteProfile removes a profile
+func DeleteProfile(path, name string) (err error) {
+	var p string
+	if p, err = ProfilePath(path, name); err != nil {
+		return
+	}
+	if err = os.Remove(p); os.IsNotExist(err) {
+		err = errors.New("no profile named '" + name + "'")
+	}
+	return
+}
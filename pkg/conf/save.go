@@ -0,0 +1,291 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupSuffix is appended, with a timestamp, to the names of the copies Save makes of the previous file
+const BackupSuffix = ".bak"
+
+const backupStamp = "20060102-150405"
+
+// Save updates the config file at path so that loading it gives values. Only keys whose values differ from what
+// the file currently loads as are touched: their lines in the file itself are rewritten in place, keys it does not
+// set are appended, and removed keys have their lines dropped, so comments, include directives and the conf.d
+// overlay are left as they are. Rewritten enc: values are sealed again and an encrypted file stays encrypted,
+// both with the passphrase. A key whose value would still come from an included or conf.d file is an error.
+// If the file already exists it is first copied to a timestamped backup beside it, whose name is returned. The
+// new file replaces the old one atomically and keeps its permissions
+func Save(path string, values map[string]string, passphrase []byte) (backup string, err error) {
+	var abs string
+	if abs, err = filepath.Abs(path); err != nil {
+		return
+	}
+	var old []byte
+	exists := true
+	if old, err = ioutil.ReadFile(abs); os.IsNotExist(err) {
+		exists, err = false, nil
+	} else if err != nil {
+		return
+	}
+	src := old
+	if Encrypted(old) {
+		if src, err = Decrypt(old, passphrase); err != nil {
+			return
+		}
+	}
+	var own, all []Entry
+	if own, err = Parse(abs, string(src)); err != nil {
+		return
+	}
+	if exists {
+		if all, err = load(abs, passphrase, nil, nil); err != nil {
+			return
+		}
+	}
+	var more []Entry
+	if more, err = overlay(abs, passphrase, nil); err != nil {
+		return
+	}
+	all = append(all, more...)
+	var lines map[int]string
+	var tail []string
+	if lines, tail, err = changes(abs, own, all, values, passphrase); err != nil {
+		return
+	}
+	if len(lines) == 0 && len(tail) == 0 && exists {
+		return
+	}
+	out := strings.Split(string(src), "\n")
+	var b strings.Builder
+	for i, l := range out {
+		if nl, ok := lines[i+1]; ok {
+			if nl == "" {
+				continue
+			}
+			l = nl
+		}
+		b.WriteString(l)
+		if i < len(out)-1 {
+			b.WriteByte('\n')
+		}
+	}
+	if len(tail) > 0 {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strings.Join(tail, "\n") + "\n")
+	}
+	data := []byte(b.String())
+	if Encrypted(old) {
+		if data, err = Encrypt(data, passphrase); err != nil {
+			return
+		}
+	}
+	if exists {
+		if backup, err = writeBackup(abs, old); err != nil {
+			return
+		}
+	}
+	var tmp *os.File
+	if tmp, err = ioutil.TempFile(filepath.Dir(abs), filepath.Base(abs)+".tmp"); err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if exists {
+		var fi os.FileInfo
+		if fi, err = os.Stat(abs); err == nil {
+			err = tmp.Chmod(fi.Mode().Perm())
+		}
+		if err != nil {
+			tmp.Close()
+			return
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	err = os.Rename(tmp.Name(), abs)
+	return
+}
+
+// changes works out the edits Save makes to the file abs, whose own lines are own and which together with its
+// includes and overlay loads as all. lines maps line numbers to their replacement, with "" for lines to drop, and
+// tail holds the lines to append
+func changes(abs string, own, all []Entry, values map[string]string, passphrase []byte) (
+	lines map[int]string, tail []string, err error) {
+	raw := make(map[int]Entry, len(own))
+	for _, e := range own {
+		raw[e.Line] = e
+	}
+	loaded := Values(all)
+	lines = make(map[int]string)
+	keys := make([]string, 0, len(values)+len(loaded))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	for k := range loaded {
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, keep := values[k]
+		if o, ok := loaded[k]; ok == keep && o == v {
+			continue
+		}
+		// last is the position in all of the last line of the file itself setting k, or -1
+		last := -1
+		for i, e := range all {
+			if e.Key == k && e.File == abs {
+				last = i
+			}
+		}
+		for i, e := range all {
+			if e.Key == k && e.File != abs && (!keep || i > last) {
+				return nil, nil, fmt.Errorf("%s is also set in %s:%d, which %s cannot override",
+					k, e.File, e.Line, abs)
+			}
+		}
+		if !keep {
+			for _, e := range own {
+				if e.Key == k {
+					lines[e.Line] = ""
+				}
+			}
+			continue
+		}
+		if last < 0 {
+			tail = append(tail, k+" = "+v)
+			continue
+		}
+		for _, e := range own {
+			if e.Key != k {
+				continue
+			}
+			nv := v
+			if strings.HasPrefix(raw[e.Line].Value, SecretPrefix) {
+				if nv, err = SealValue(v, passphrase); err != nil {
+					return nil, nil, fmt.Errorf("%s:%d: %v", e.File, e.Line, err)
+				}
+			}
+			lines[e.Line] = k + " = " + nv
+		}
+	}
+	return
+}
+
+// writeBackup copies old to a new backup of path named for the current time, adding a counter when a backup
+// from the same second already exists
+func writeBackup(path string, old []byte) (name string, err error) {
+	base := path + "." + time.Now().Format(backupStamp)
+	for n := 0; ; n++ {
+		name = base + BackupSuffix
+		if n > 0 {
+			name = base + "." + strconv.Itoa(n) + BackupSuffix
+		}
+		var f *os.File
+		if f, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600); os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if _, err = f.Write(old); err != nil {
+			f.Close()
+			os.Remove(name)
+			return "", err
+		}
+		return name, f.Close()
+	}
+}
+
+// Backups returns the backups Save has made of path, oldest first
+func Backups(path string) (out []string, err error) {
+	if out, err = filepath.Glob(path + ".*" + BackupSuffix); err != nil {
+		return
+	}
+	sort.Slice(out, func(i, j int) bool {
+		si, ni := backupOrder(path, out[i])
+		sj, nj := backupOrder(path, out[j])
+		if si != sj {
+			return si < sj
+		}
+		return ni < nj
+	})
+	return
+}
+
+// backupOrder splits a backup name into its timestamp and counter
+func backupOrder(path, name string) (stamp string, n int) {
+	stamp = strings.TrimSuffix(strings.TrimPrefix(name, path+"."), BackupSuffix)
+	if i := strings.IndexByte(stamp, '.'); i >= 0 {
+		n, _ = strconv.Atoi(stamp[i+1:])
+		stamp = stamp[:i]
+	}
+	return
+}
+
+// Change is a key whose value differs between two sets of values. Added and Removed are set when the key is
+// only present on one side
+type Change struct {
+	Key     string
+	Old     string
+	New     string
+	Added   bool
+	Removed bool
+}
+
+// Diff compares saved values with the current effective values and returns the changes sorted by key
+func Diff(saved, current map[string]string) (out []Change) {
+	for k, o := range saved {
+		n, ok := current[k]
+		switch {
+		case !ok:
+			out = append(out, Change{Key: k, Old: o, Removed: true})
+		case n != o:
+			out = append(out, Change{Key: k, Old: o, New: n})
+		}
+	}
+	for k, n := range current {
+		if _, ok := saved[k]; !ok {
+			out = append(out, Change{Key: k, New: n, Added: true})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return
+}
+
+// WriteDiff prints changes in unified diff style, with from and to naming the two sides, as `conf diff` shows them
+func WriteDiff(w io.Writer, from, to string, changes []Change) (err error) {
+	if len(changes) == 0 {
+		return
+	}
+	if _, err = fmt.Fprintf(w, "--- %s\n+++ %s\n", from, to); err != nil {
+		return
+	}
+	for _, c := range changes {
+		fmt.Fprintf(w, "@@ %s @@\n", c.Key)
+		if !c.Added {
+			fmt.Fprintf(w, "-%s = %s\n", c.Key, c.Old)
+		}
+		if !c.Removed {
+			if _, err = fmt.Fprintf(w, "+%s = %s\n", c.Key, c.New); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,200 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// saveDir makes a directory holding pod.conf with the given contents, returning the path of the file
+func saveDir(t *testing.T, contents string) (path string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "skele-save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path = filepath.Join(dir, "pod.conf")
+	if err = ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// loaded loads path and returns its values
+func loaded(t *testing.T, path string, passphrase []byte) map[string]string {
+	t.Helper()
+	e, err := LoadEncrypted(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Values(e)
+}
+
+func TestSaveRewritesInPlace(t *testing.T) {
+	path := saveDir(t, "# node settings\nport = 1\nlisten = a\n")
+	v := loaded(t, path, nil)
+	v["port"] = "2"
+	backup, err := Save(path, v, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "# node settings\nport = 2\nlisten = a\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := readFile(t, backup); got != "# node settings\nport = 1\nlisten = a\n" {
+		t.Fatalf("backup holds %q", got)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0644 {
+		t.Fatalf("mode changed to %v", fi.Mode().Perm())
+	}
+}
+
+func TestSaveRemovesKey(t *testing.T) {
+	path := saveDir(t, "port = 1\n# keep me\nlisten = a\n")
+	v := loaded(t, path, nil)
+	delete(v, "listen")
+	if _, err := Save(path, v, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "port = 1\n# keep me\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSaveAppendsNewKey(t *testing.T) {
+	path := saveDir(t, "port = 1")
+	v := loaded(t, path, nil)
+	v["listen"] = "a"
+	if _, err := Save(path, v, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "port = 1\nlisten = a\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSaveLeavesIncludesAndOverlay(t *testing.T) {
+	path := saveDir(t, "include = extra.conf\nport = 1\n")
+	writeFile(t, filepath.Join(filepath.Dir(path), "extra.conf"), "debug = 1\n")
+	writeFile(t, filepath.Join(filepath.Dir(path), OverlayDir, "net.conf"), "network = test\n")
+	v := loaded(t, path, nil)
+	v["port"] = "2"
+	if _, err := Save(path, v, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "include = extra.conf\nport = 2\n"; got != want {
+		t.Fatalf("included or overlay values were copied in: %q", got)
+	}
+	for _, key := range []string{"debug", "network"} {
+		v = loaded(t, path, nil)
+		v[key] = "changed"
+		if _, err := Save(path, v, nil); err == nil || !strings.Contains(err.Error(), key+" is also set in") {
+			t.Fatalf("changing %s gave %v", key, err)
+		}
+		v = loaded(t, path, nil)
+		delete(v, key)
+		if _, err := Save(path, v, nil); err == nil {
+			t.Fatalf("removing %s was not an error", key)
+		}
+	}
+	if got := readFile(t, path); got != "include = extra.conf\nport = 2\n" {
+		t.Fatalf("failed saves changed the file: %q", got)
+	}
+}
+
+func TestSaveKeepsSecretsSealed(t *testing.T) {
+	pass := []byte("pass")
+	user, err := SealValue("bob", pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpcpass, err := SealValue("hunter2", pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := saveDir(t, "rpcuser = "+user+"\nrpcpass = "+rpcpass+"\nport = 1\n")
+	v := loaded(t, path, pass)
+	v["rpcpass"], v["port"] = "swordfish", "2"
+	if _, err = Save(path, v, pass); err != nil {
+		t.Fatal(err)
+	}
+	got := readFile(t, path)
+	if strings.Contains(got, "swordfish") || strings.Contains(got, "bob") {
+		t.Fatalf("secret written in plain text: %q", got)
+	}
+	if !strings.Contains(got, "rpcuser = "+user+"\n") {
+		t.Fatalf("unchanged secret was rewritten: %q", got)
+	}
+	v = loaded(t, path, pass)
+	if v["rpcuser"] != "bob" || v["rpcpass"] != "swordfish" || v["port"] != "2" {
+		t.Fatalf("loaded back %v", v)
+	}
+}
+
+func TestSaveKeepsFileEncrypted(t *testing.T) {
+	pass := []byte("pass")
+	b, err := Encrypt([]byte("rpcpass = hunter2\n"), pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := saveDir(t, string(b))
+	v := loaded(t, path, pass)
+	v["rpcpass"] = "swordfish"
+	if _, err = Save(path, v, pass); err != nil {
+		t.Fatal(err)
+	}
+	got := readFile(t, path)
+	if !Encrypted([]byte(got)) || strings.Contains(got, "swordfish") {
+		t.Fatalf("file is no longer encrypted: %q", got)
+	}
+	if v = loaded(t, path, pass); v["rpcpass"] != "swordfish" {
+		t.Fatalf("loaded back %v", v)
+	}
+}
+
+func TestSaveBackupsAreUnique(t *testing.T) {
+	path := saveDir(t, "port = 0\n")
+	for _, port := range []string{"1", "2", "3"} {
+		if _, err := Save(path, map[string]string{"port": port}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := Backups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("got %d backups, want 3", len(backups))
+	}
+	for i, b := range backups {
+		if got, want := readFile(t, b), "port = "+string(rune('0'+i))+"\n"; got != want {
+			t.Fatalf("backup %d holds %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package conf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is how often sources are polled for changes when no interval is set
+const DefaultInterval = 30 * time.Second
+
+// Update is sent by a Source being watched when its entries change or it fails to read them
+type Update struct {
+	Entries []Entry
+	Err     error
+}
+
+// Source is somewhere config entries can be read from, and watched for changes so that live parameters can be
+// reloaded
+type Source interface {
+	// Get returns the current entries
+	Get() ([]Entry, error)
+	// Watch sends an Update every time the entries change, until stop is closed
+	Watch(stop <-chan struct{}) <-chan Update
+}
+
+// File is a Source reading a config file with LoadEncrypted, detecting changes by the modification times of the
+// file, the files it includes and those in its conf.d directory
+type File struct {
+	Path     string
+	Interval time.Duration
+	// Passphrase opens encrypted files and enc: values
+	Passphrase []byte
+}
+
+// Get loads the file
+func (f *File) Get() ([]Entry, error) {
+	return LoadEncrypted(f.Path, f.Passphrase)
+}
+
+// Watch polls the modification times of every file the last load read, and the list of files in conf.d, so that
+// overlays being added or removed are also seen
+func (f *File) Watch(stop <-chan struct{}) <-chan Update {
+	var files []string
+	var last map[string]time.Time
+	return poll(stop, f.Interval, func() (e []Entry, changed bool, err error) {
+		if last != nil && sameTimes(last, modTimes(f.Path, files)) {
+			return
+		}
+		files = files[:0]
+		e, err = loadAll(f.Path, f.Passphrase, &files)
+		last = modTimes(f.Path, files)
+		return e, true, err
+	})
+}
+
+// modTimes returns the modification times of files and of the overlays of path, with files that cannot be read
+// given the zero time
+func modTimes(path string, files []string) map[string]time.Time {
+	out := make(map[string]time.Time, len(files))
+	overlays, _ := filepath.Glob(filepath.Join(filepath.Dir(path), OverlayDir, "*.conf"))
+	for _, name := range append(append([]string{path}, files...), overlays...) {
+		if name, err := filepath.Abs(name); err == nil {
+			var t time.Time
+			if fi, err := os.Stat(name); err == nil {
+				t = fi.ModTime()
+			}
+			out[name] = t
+		}
+	}
+	return out
+}
+
+func sameTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, t := range a {
+		if u, ok := b[name]; !ok || !u.Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// HTTP is a Source reading a config file from a URL. Responses are cached by ETag so an unchanged file is not
+// downloaded or parsed again, and by a hash of the body for servers that send no ETag. The file may be encrypted
+// or hold enc: values as a local one can, but include directives are an error, as there is nowhere to read them from
+type HTTP struct {
+	URL      string
+	Client   *http.Client
+	Interval time.Duration
+	// Passphrase opens an encrypted file and enc: values
+	Passphrase []byte
+
+	mx      sync.Mutex
+	etag    string
+	sum     [sha256.Size]byte
+	entries []Entry
+}
+
+// Get fetches the file, or returns the cached entries if the server reports it is unchanged
+func (h *HTTP) Get() (out []Entry, err error) {
+	out, _, err = h.fetch()
+	return
+}
+
+// Watch polls the URL, sending an update when the file changes
+func (h *HTTP) Watch(stop <-chan struct{}) <-chan Update {
+	return poll(stop, h.Interval, h.fetch)
+}
+
+func (h *HTTP) fetch() (out []Entry, changed bool, err error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	var req *http.Request
+	if req, err = http.NewRequest("GET", h.URL, nil); err != nil {
+		return
+	}
+	if h.etag != "" {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var res *http.Response
+	if res, err = client.Do(req); err != nil {
+		return
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		return h.entries, false, nil
+	case http.StatusOK:
+	default:
+		return nil, false, fmt.Errorf("%s: %s", h.URL, res.Status)
+	}
+	var b []byte
+	if b, err = ioutil.ReadAll(res.Body); err != nil {
+		return
+	}
+	etag, sum := res.Header.Get("ETag"), sha256.Sum256(b)
+	if sum == h.sum {
+		h.etag = etag
+		return h.entries, false, nil
+	}
+	if out, err = decode(h.URL, b, h.Passphrase); err != nil {
+		return
+	}
+	for _, e := range out {
+		if e.Key == IncludeKey {
+			return nil, false, fmt.Errorf("%s:%d: include is not supported in remote config", e.File, e.Line)
+		}
+	}
+	h.etag, h.sum, h.entries = etag, sum, out
+	return out, true, nil
+}
+
+// poll calls fetch at every interval and sends the result when it changed or failed
+func poll(stop <-chan struct{}, interval time.Duration, fetch func() ([]Entry, bool, error)) <-chan Update {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	out := make(chan Update)
+	go func() {
+		defer close(out)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			if e, changed, err := fetch(); changed || err != nil {
+				select {
+				case out <- Update{e, err}:
+				case <-stop:
+					return
+				}
+			}
+			select {
+			case <-t.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Kind is the stage of command execution an Event reports
+type Kind int
+
+// The stages of executing a command, in the order they occur. Error is sent instead of End when the handler fails
+const (
+	Dispatch Kind = iota
+	Start
+	End
+	Error
+)
+
+var kindNames = []string{"dispatch", "start", "end", "error"}
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// Event is one stage of a command's execution. Duration is set on End and Error, and Err on Error
+type Event struct {
+	Kind     Kind
+	Path     string
+	Time     time.Time
+	Duration time.Duration
+	Err      error
+}
+
+type subscriber struct {
+	ch      chan Event
+	dropped uint64
+}
+
+var (
+	mx   sync.Mutex
+	subs = map[*subscriber]struct{}{}
+)
+
+// Subscribe returns a channel receiving every event, buffered to hold buf of them, and a function that stops the
+// subscription and closes the channel. Events are dropped rather than stall a command when the buffer is full
+func Subscribe(buf int) (<-chan Event, func() (dropped uint64)) {
+	s := &subscriber{ch: make(chan Event, buf)}
+	mx.Lock()
+	subs[s] = struct{}{}
+	mx.Unlock()
+	return s.ch, func() uint64 {
+		mx.Lock()
+		defer mx.Unlock()
+		if _, ok := subs[s]; ok {
+			delete(subs, s)
+			close(s.ch)
+		}
+		return s.dropped
+	}
+}
+
+// Publish sends an event to every subscriber
+func Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	mx.Lock()
+	defer mx.Unlock()
+	for s := range subs {
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped++
+		}
+	}
+}
+
+// Run publishes the events for executing fn as the handler of the command at path
+func Run(path string, fn func() error) (err error) {
+	Publish(Event{Kind: Dispatch, Path: path})
+	start := time.Now()
+	Publish(Event{Kind: Start, Path: path, Time: start})
+	err = fn()
+	e := Event{Kind: End, Path: path, Duration: time.Since(start)}
+	if err != nil {
+		e.Kind, e.Err = Error, err
+	}
+	Publish(e)
+	return
+}
@@ -0,0 +1,94 @@
+package fail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes returned by skele applications. These are documented in the README and must not change
+const (
+	CodeOK         = 0
+	CodeHandler    = 1
+	CodeUsage      = 2
+	CodeValidation = 3
+)
+
+// Error is an error carrying the process exit code it should result in
+type Error struct {
+	Code int
+	Err  error
+}
+
+// Error returns the message of the wrapped error
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New wraps err with the given exit code, returning nil if err is nil
+func New(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{code, err}
+}
+
+// Handler marks err as having come from a command handler
+func Handler(err error) error {
+	return New(CodeHandler, err)
+}
+
+// Usage marks err as a problem with how the command line was written
+func Usage(err error) error {
+	return New(CodeUsage, err)
+}
+
+// Validation marks err as a parameter value that was read but is not acceptable
+func Validation(err error) error {
+	return New(CodeValidation, err)
+}
+
+// Code returns the exit code for err. Nil is CodeOK, errors without a code anywhere in their chain are CodeHandler,
+// and a Multi has the highest code of the errors in it, and at least CodeHandler
+func Code(err error) int {
+	if err == nil {
+		return CodeOK
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	var m Multi
+	if errors.As(err, &m) {
+		code := CodeHandler
+		for _, sub := range m {
+			if c := Code(sub); c > code {
+				code = c
+			}
+		}
+		return code
+	}
+	return CodeHandler
+}
+
+// Exit prints err to stderr, if there is one, and exits with its code
+func Exit(err error) {
+	os.Exit(Print(os.Stderr, err))
+}
+
+// Print writes err to w, if there is one, and returns its exit code
+func Print(w io.Writer, err error) int {
+	if err != nil {
+		fmt.Fprintln(w, "error:", err)
+	}
+	return Code(err)
+}
@@ -0,0 +1,45 @@
+package fail
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Multi collects every failure found in one run of parsing or validation, so they can all be reported at once
+// instead of stopping at the first
+type Multi []error
+
+// Add appends err if it is not nil, flattening another Multi into this one
+func (m *Multi) Add(err error) {
+	switch e := err.(type) {
+	case nil:
+	case Multi:
+		*m = append(*m, e...)
+	default:
+		*m = append(*m, err)
+	}
+}
+
+// Err returns nil if nothing was collected, the error itself if there is only one, and the Multi otherwise
+func (m Multi) Err() error {
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	}
+	return m
+}
+
+// Error renders the errors as a bulleted list under a count
+func (m Multi) Error() string {
+	if len(m) == 1 {
+		return m[0].Error()
+	}
+	lines := make([]string, 0, len(m)+1)
+	lines = append(lines, strconv.Itoa(len(m))+" errors:")
+	for _, e := range m {
+		lines = append(lines, "  - "+strings.Replace(e.Error(), "\n", "\n    ", -1))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,256 @@
+package flagstruct
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"git.parallelcoin.io/pod/pkg/util/base58"
+	"github.com/l0k1verloren/skele/pkg/T"
+	"github.com/l0k1verloren/skele/pkg/fail"
+)
+
+// Struct builds a struct with one field per node under root, tagged as go-flags reads them, and fills it with the
+// current values, so code written against a flags-parsed config struct can use values skele parsed. Parameters
+// become fields tagged long and description, with the standard Go type of their value, such as time.Duration, and
+// subcommands nested structs tagged command. The result is a pointer to the new struct
+func Struct(root T.Cmd) (out interface{}, err error) {
+	var t reflect.Type
+	if t, err = structType(root); err != nil {
+		return
+	}
+	v := reflect.New(t)
+	if err = Fill(v.Interface(), root); err != nil {
+		return
+	}
+	return v.Interface(), nil
+}
+
+// Fill copies the values of the nodes under root into dst, a pointer to a struct tagged as go-flags reads them,
+// such as a legacy config struct. Fields tagged long take the value of the parameter with that name, fields tagged
+// command are filled from the subcommand with that name, and group and embedded structs are filled from the same
+// node. Fields with no matching node, or whose node has no value, are left as they are. Values are converted to the
+// field's type where Go allows it, lists element by element, and other types are written as text into string
+// fields. Every value that cannot be stored is reported, in a fail.Multi
+func Fill(dst interface{}, root T.Cmd) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("flagstruct: destination must be a pointer to a struct")
+	}
+	var problems fail.Multi
+	fill(v.Elem(), root, &problems)
+	return problems.Err()
+}
+
+func fill(v reflect.Value, c T.Cmd, problems *fail.Multi) {
+	byName := make(map[string]T.Cmd)
+	for _, child := range c.List() {
+		byName[child.Name()] = child
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		if name, ok := f.Tag.Lookup("command"); ok {
+			if sub, ok := byName[name]; ok && sub.Type() == T.COMMAND.Label {
+				if s, ok := structField(fv); ok {
+					fill(s, sub, problems)
+				}
+			}
+			continue
+		}
+		if name, ok := f.Tag.Lookup("long"); ok {
+			if p, ok := byName[name]; ok && p.Type() != T.COMMAND.Label && p.Data() != nil {
+				if err := set(fv, reflect.ValueOf(p.Data())); err != nil {
+					problems.Add(fmt.Errorf("'%s': %v", p.Path(), err))
+				}
+			}
+			continue
+		}
+		if _, group := f.Tag.Lookup("group"); group || f.Anonymous {
+			if s, ok := structField(fv); ok {
+				fill(s, c, problems)
+			}
+		}
+	}
+}
+
+// structField returns the struct held by a field that is a struct or a pointer to one, allocating the pointer
+func structField(fv reflect.Value) (reflect.Value, bool) {
+	if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	return fv, fv.Kind() == reflect.Struct
+}
+
+// set stores v in the field dst, converting it to the field's type
+func set(dst, v reflect.Value) error {
+	switch {
+	case dst.Kind() == reflect.String && v.Kind() != reflect.String:
+		dst.SetString(text(v.Interface()))
+	case v.Type().ConvertibleTo(dst.Type()):
+		dst.Set(v.Convert(dst.Type()))
+	case v.Kind() == reflect.Slice && dst.Kind() == reflect.Slice:
+		out := reflect.MakeSlice(dst.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := set(out.Index(i), v.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+	default:
+		return fmt.Errorf("cannot store %s in a field of type %s", v.Type(), dst.Type())
+	}
+	return nil
+}
+
+// text writes a value in the form skele parses it from
+func text(v interface{}) string {
+	switch x := v.(type) {
+	case T.Duration:
+		return time.Duration(x).String()
+	case T.Time:
+		return time.Time(x).Format("15:04:05")
+	case T.Date:
+		return time.Time(x).Format("2006-01-02")
+	case T.Base32:
+		return base32.StdEncoding.EncodeToString(x)
+	case T.Base58:
+		if len(x) > 0 {
+			return base58.CheckEncode(x[1:], x[0])
+		}
+		return ""
+	case T.IPNet:
+		return (*net.IPNet)(x).String()
+	case T.PortRange:
+		return strconv.Itoa(int(x[0])) + "-" + strconv.Itoa(int(x[1]))
+	}
+	return fmt.Sprint(v)
+}
+
+// structType builds the struct type for the children of c
+func structType(c T.Cmd) (t reflect.Type, err error) {
+	var fields []reflect.StructField
+	var problems fail.Multi
+	seen := make(map[string]string)
+	for _, child := range c.List() {
+		name := exportName(child.Name())
+		if name == "" {
+			problems.Add(fmt.Errorf("'%s' has no name usable as a field", child.Path()))
+			continue
+		}
+		if other, ok := seen[name]; ok {
+			problems.Add(fmt.Errorf("'%s' and '%s' both become the field %s", other, child.Path(), name))
+			continue
+		}
+		seen[name] = child.Path()
+		tag := `description:"` + strings.Replace(child.Description(), `"`, `'`, -1) + `"`
+		var ft reflect.Type
+		if child.Type() == T.COMMAND.Label {
+			if ft, err = structType(child); err != nil {
+				problems.Add(err)
+				continue
+			}
+			tag = `command:"` + child.Name() + `" ` + tag
+		} else {
+			if ft = valueType(child); ft == nil {
+				problems.Add(fmt.Errorf("'%s' has no value and the unknown type '%s'", child.Path(), child.Type()))
+				continue
+			}
+			tag = `long:"` + child.Name() + `" ` + tag
+		}
+		fields = append(fields, reflect.StructField{Name: name, Type: ft, Tag: reflect.StructTag(tag)})
+	}
+	if err = problems.Err(); err != nil {
+		return
+	}
+	return reflect.StructOf(fields), nil
+}
+
+// valueType is the type of a parameter's value, or of the template for its type when it has no value, given as
+// the plain Go type code outside skele would use
+func valueType(c T.Cmd) reflect.Type {
+	if d := c.Data(); d != nil {
+		return plain(reflect.TypeOf(d))
+	}
+	for _, k := range T.Types {
+		if k.Label == c.Type() && k.Template != nil {
+			return plain(reflect.TypeOf(k.Template))
+		}
+	}
+	return nil
+}
+
+var (
+	tPackage = reflect.TypeOf(T.Key{}).PkgPath()
+	// plainTypes are the types of package T that are not simply their underlying type
+	plainTypes = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(T.Duration(0)): reflect.TypeOf(time.Duration(0)),
+		reflect.TypeOf(T.Time{}):      reflect.TypeOf(time.Time{}),
+		reflect.TypeOf(T.Date{}):      reflect.TypeOf(time.Time{}),
+		reflect.TypeOf(T.IPNet(nil)):  reflect.TypeOf((*net.IPNet)(nil)),
+	}
+	basicTypes = map[reflect.Kind]reflect.Type{}
+)
+
+func init() {
+	for _, v := range []interface{}{false, int(0), int8(0), int16(0), int32(0), int64(0), uint(0), uint8(0),
+		uint16(0), uint32(0), uint64(0), float32(0), float64(0), ""} {
+		basicTypes[reflect.TypeOf(v).Kind()] = reflect.TypeOf(v)
+	}
+}
+
+// plain replaces the named types of package T in t with the standard types they stand for, such as time.Duration
+// for T.Duration and []int for T.IntList
+func plain(t reflect.Type) reflect.Type {
+	if p, ok := plainTypes[t]; ok {
+		return p
+	}
+	if t.PkgPath() != tPackage && t.Name() != "" {
+		return t
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return reflect.SliceOf(plain(t.Elem()))
+	case reflect.Array:
+		return reflect.ArrayOf(t.Len(), plain(t.Elem()))
+	}
+	if b, ok := basicTypes[t.Kind()]; ok {
+		return b
+	}
+	return t
+}
+
+// exportName turns a node name such as rpc-user into an exported field name such as RpcUser, starting it with X
+// when its first letter has no upper case
+func exportName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			if b.Len() == 0 && !unicode.IsUpper(r) {
+				b.WriteByte('X')
+			}
+			b.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
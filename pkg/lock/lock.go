@@ -0,0 +1,67 @@
+package lock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Name is the file created in the locked directory, holding the process ID of the owner
+const Name = ".lock"
+
+// File is a held lock on a directory
+type File struct {
+	f *os.File
+}
+
+// Lock takes an advisory lock on dir, typically the data directory given by a Path parameter, so a second instance
+// of a daemon cannot use it at the same time. If another process holds it the error names its PID
+func Lock(dir string) (l *File, err error) {
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	path := filepath.Join(dir, Name)
+	var f *os.File
+	if f, err = acquire(path); err != nil {
+		if err == errLocked {
+			err = fmt.Errorf("%s is in use by another process%s", dir, owner(path))
+		}
+		return
+	}
+	if err = f.Truncate(0); err == nil {
+		_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	}
+	if err != nil {
+		f.Close()
+		return
+	}
+	return &File{f}, nil
+}
+
+// Unlock releases the lock. The lock file is emptied but left in place: removing it would let one process lock the
+// unlinked file while another creates and locks a new one, and both would then use the directory
+func (l *File) Unlock() (err error) {
+	if l == nil || l.f == nil {
+		return
+	}
+	l.f.Truncate(0)
+	err = l.f.Close()
+	l.f = nil
+	return
+}
+
+// owner reads the PID written by the process holding the lock, for the error message
+func owner(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	pid := strings.TrimSpace(string(b))
+	if _, err = strconv.Atoi(pid); err != nil {
+		return ""
+	}
+	return " (pid " + pid + ")"
+}
@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+)
+
+var errLocked = errors.New("locked")
+
+func acquire(path string) (*os.File, error) {
+	return nil, errors.New("directory locking is not supported on this platform")
+}
@@ -0,0 +1,29 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var errLocked = errors.New("locked")
+
+const errSharingViolation syscall.Errno = 32
+
+// acquire opens the file allowing other processes only to read it, which holds it until it is closed
+func acquire(path string) (f *os.File, err error) {
+	var p *uint16
+	if p, err = syscall.UTF16PtrFromString(path); err != nil {
+		return
+	}
+	var h syscall.Handle
+	h, err = syscall.CreateFile(p, syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if err == errSharingViolation {
+			err = errLocked
+		}
+		return
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package nat
+
+import (
+	"errors"
+	"net"
+)
+
+// Gateway is only implemented for Linux, so NAT-PMP is not available elsewhere. UPnP discovery does not
+// need it
+func Gateway() (net.IP, error) {
+	return nil, errors.New("finding the default gateway is not supported on this platform")
+}
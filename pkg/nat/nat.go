@@ -0,0 +1,123 @@
+package nat
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLifetime is how long mappings are requested for. They are renewed well before they expire
+const DefaultLifetime = time.Hour
+
+// NAT is a router that can forward a port from its external address to this host
+type NAT interface {
+	// Kind names the protocol used, for status output
+	Kind() string
+	ExternalIP() (net.IP, error)
+	// AddPortMapping forwards external to internal, returning the external port the router actually used
+	AddPortMapping(protocol string, internal, external int, desc string, lifetime time.Duration) (int, error)
+	DeletePortMapping(protocol string, internal, external int) error
+}
+
+// ErrNotFound is returned by Discover when no router answers with a supported protocol
+var ErrNotFound = errors.New("no UPnP or NAT-PMP capable router found")
+
+// Discover looks for a router speaking UPnP, then NAT-PMP, waiting at most timeout for each
+func Discover(timeout time.Duration) (NAT, error) {
+	if n, err := DiscoverUPnP(timeout); err == nil {
+		return n, nil
+	}
+	if n, err := DiscoverPMP(timeout); err == nil {
+		return n, nil
+	}
+	return nil, ErrNotFound
+}
+
+// Mapping is a port forwarded for the life of the application, as requested by a Port parameter marked to be
+// mapped. It is renewed in the background until Close removes it
+type Mapping struct {
+	nat      NAT
+	protocol string
+	internal int
+	desc     string
+
+	mx       sync.Mutex
+	external int
+	err      error
+	renewed  time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Map forwards the port on the router and keeps it forwarded until Close is called at shutdown
+func Map(n NAT, protocol string, port int, desc string) (m *Mapping, err error) {
+	m = &Mapping{
+		nat:      n,
+		protocol: protocol,
+		internal: port,
+		desc:     desc,
+		external: port,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err = m.renew(); err != nil {
+		return nil, err
+	}
+	go m.keep()
+	return
+}
+
+func (m *Mapping) renew() error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	ext, err := m.nat.AddPortMapping(m.protocol, m.internal, m.external, m.desc, DefaultLifetime)
+	m.err = err
+	if err == nil {
+		m.external, m.renewed = ext, time.Now()
+	}
+	return err
+}
+
+func (m *Mapping) keep() {
+	defer close(m.done)
+	t := time.NewTicker(DefaultLifetime / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			m.renew()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// External is the port forwarded on the router
+func (m *Mapping) External() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.external
+}
+
+// Status describes the mapping for the health status report
+func (m *Mapping) Status() (key, value string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	key = "nat." + m.protocol + "." + strconv.Itoa(m.internal)
+	if m.err != nil {
+		return key, m.nat.Kind() + " error: " + m.err.Error()
+	}
+	return key, m.nat.Kind() + " external port " + strconv.Itoa(m.external) +
+		", renewed " + m.renewed.Format(time.RFC3339)
+}
+
+// Close stops renewing the mapping and removes it from the router
+func (m *Mapping) Close() error {
+	close(m.stop)
+	<-m.done
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.nat.DeletePortMapping(m.protocol, m.internal, m.external)
+}
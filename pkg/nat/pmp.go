@@ -0,0 +1,130 @@
+package nat
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pmpPort      = 5351
+	pmpVersion   = 0
+	pmpTries     = 4
+	pmpFirstWait = 250 * time.Millisecond
+)
+
+var pmpResults = []string{
+	"success",
+	"unsupported version",
+	"not authorized or refused",
+	"network failure",
+	"out of resources",
+	"unsupported opcode",
+}
+
+// pmp speaks NAT-PMP (RFC 6886) to the default gateway
+type pmp struct {
+	gateway net.IP
+	timeout time.Duration
+}
+
+// DiscoverPMP finds the default gateway and checks that it answers NAT-PMP requests
+func DiscoverPMP(timeout time.Duration) (NAT, error) {
+	gw, err := Gateway()
+	if err != nil {
+		return nil, err
+	}
+	n := &pmp{gw, timeout}
+	if _, err = n.ExternalIP(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Kind is NAT-PMP
+func (n *pmp) Kind() string {
+	return "NAT-PMP"
+}
+
+// ExternalIP asks the gateway for its public address
+func (n *pmp) ExternalIP() (ip net.IP, err error) {
+	var res []byte
+	if res, err = n.call([]byte{pmpVersion, 0}, 12); err != nil {
+		return
+	}
+	return net.IPv4(res[8], res[9], res[10], res[11]), nil
+}
+
+// AddPortMapping requests the mapping, which the gateway may grant on a different external port
+func (n *pmp) AddPortMapping(protocol string, internal, external int, desc string,
+	lifetime time.Duration) (mapped int, err error) {
+	var res []byte
+	if res, err = n.mapping(protocol, internal, external, lifetime); err != nil {
+		return
+	}
+	return int(binary.BigEndian.Uint16(res[10:12])), nil
+}
+
+// DeletePortMapping asks for a mapping with no lifetime, which removes it
+func (n *pmp) DeletePortMapping(protocol string, internal, external int) (err error) {
+	_, err = n.mapping(protocol, internal, 0, 0)
+	return
+}
+
+func (n *pmp) mapping(protocol string, internal, external int, lifetime time.Duration) ([]byte, error) {
+	req := make([]byte, 12)
+	req[0] = pmpVersion
+	switch strings.ToLower(protocol) {
+	case "udp":
+		req[1] = 1
+	case "tcp":
+		req[1] = 2
+	default:
+		return nil, errors.New("unknown protocol " + protocol)
+	}
+	binary.BigEndian.PutUint16(req[4:6], uint16(internal))
+	binary.BigEndian.PutUint16(req[6:8], uint16(external))
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+	return n.call(req, 16)
+}
+
+// call sends the request, retrying with doubling waits as the RFC asks, and checks the response matches it
+func (n *pmp) call(req []byte, size int) (res []byte, err error) {
+	var c net.Conn
+	addr := net.JoinHostPort(n.gateway.String(), strconv.Itoa(pmpPort))
+	if c, err = net.Dial("udp", addr); err != nil {
+		return
+	}
+	defer c.Close()
+	deadline := time.Now().Add(n.timeout)
+	res = make([]byte, 16)
+	wait := pmpFirstWait
+	for try := 0; try < pmpTries && time.Now().Before(deadline); try, wait = try+1, wait*2 {
+		if _, err = c.Write(req); err != nil {
+			return
+		}
+		c.SetReadDeadline(time.Now().Add(wait))
+		var got int
+		if got, err = c.Read(res); err != nil {
+			continue
+		}
+		if got < size || res[0] != pmpVersion || res[1] != req[1]|0x80 {
+			err = errors.New("malformed NAT-PMP response")
+			continue
+		}
+		if code := binary.BigEndian.Uint16(res[2:4]); code != 0 {
+			if int(code) < len(pmpResults) {
+				return nil, errors.New("NAT-PMP: " + pmpResults[code])
+			}
+			return nil, errors.New("NAT-PMP: error " + strconv.Itoa(int(code)))
+		}
+		return res[:got], nil
+	}
+	if err == nil {
+		err = errors.New("NAT-PMP: no response from " + addr)
+	}
+	return nil, err
+}
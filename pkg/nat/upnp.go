@@ -0,0 +1,219 @@
+package nat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ssdpAddr   = "239.255.255.250:1900"
+	igdDevice  = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	soapPrefix = `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" ` +
+		`s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`
+	soapSuffix = `</s:Body></s:Envelope>`
+)
+
+// upnp controls an Internet Gateway Device through its WANIPConnection or WANPPPConnection service
+type upnp struct {
+	control string
+	service string
+	local   net.IP
+	client  *http.Client
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+	Services   []upnpService `xml:"serviceList>service"`
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// DiscoverUPnP searches the local network for an Internet Gateway Device by SSDP
+func DiscoverUPnP(timeout time.Duration) (NAT, error) {
+	location, err := ssdpSearch(timeout)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: timeout}
+	res, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var root struct {
+		Device upnpDevice `xml:"device"`
+	}
+	if err = xml.NewDecoder(res.Body).Decode(&root); err != nil {
+		return nil, err
+	}
+	svc, ok := findService(root.Device)
+	if !ok {
+		return nil, errors.New("gateway at " + location + " has no WAN connection service")
+	}
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	control, err := base.Parse(svc.ControlURL)
+	if err != nil {
+		return nil, err
+	}
+	local, err := localIP(base.Host)
+	if err != nil {
+		return nil, err
+	}
+	return &upnp{control.String(), svc.ServiceType, local, client}, nil
+}
+
+// ssdpSearch multicasts a search for gateways and returns the description URL of the first to answer
+func ssdpSearch(timeout time.Duration) (location string, err error) {
+	var c net.PacketConn
+	if c, err = net.ListenPacket("udp4", ":0"); err != nil {
+		return
+	}
+	defer c.Close()
+	var dst *net.UDPAddr
+	if dst, err = net.ResolveUDPAddr("udp4", ssdpAddr); err != nil {
+		return
+	}
+	req := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"ST: " + igdDevice + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n\r\n"
+	if _, err = c.WriteTo([]byte(req), dst); err != nil {
+		return
+	}
+	c.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 2048)
+	for {
+		var n int
+		if n, _, err = c.ReadFrom(buf); err != nil {
+			return "", errors.New("no UPnP gateway answered")
+		}
+		res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		res.Body.Close()
+		if strings.Contains(res.Header.Get("St"), "InternetGatewayDevice") && res.Header.Get("Location") != "" {
+			return res.Header.Get("Location"), nil
+		}
+	}
+}
+
+func findService(d upnpDevice) (upnpService, bool) {
+	for _, s := range d.Services {
+		if strings.Contains(s.ServiceType, ":WANIPConnection:") ||
+			strings.Contains(s.ServiceType, ":WANPPPConnection:") {
+			return s, true
+		}
+	}
+	for _, child := range d.Devices {
+		if s, ok := findService(child); ok {
+			return s, true
+		}
+	}
+	return upnpService{}, false
+}
+
+// localIP finds the address of this host on the interface facing the gateway
+func localIP(gateway string) (net.IP, error) {
+	host := gateway
+	if h, _, err := net.SplitHostPort(gateway); err == nil {
+		host = h
+	}
+	c, err := net.Dial("udp4", net.JoinHostPort(host, "1900"))
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// Kind is UPnP
+func (n *upnp) Kind() string {
+	return "UPnP"
+}
+
+// ExternalIP asks the gateway for its public address
+func (n *upnp) ExternalIP() (ip net.IP, err error) {
+	var res struct {
+		IP string `xml:"Body>GetExternalIPAddressResponse>NewExternalIPAddress"`
+	}
+	if err = n.call("GetExternalIPAddress", "", &res); err != nil {
+		return
+	}
+	if ip = net.ParseIP(strings.TrimSpace(res.IP)); ip == nil {
+		err = errors.New("gateway returned an invalid external address")
+	}
+	return
+}
+
+// AddPortMapping forwards the same port number externally, as most gateways do not choose ports for UPnP clients
+func (n *upnp) AddPortMapping(protocol string, internal, external int, desc string,
+	lifetime time.Duration) (int, error) {
+	args := fmt.Sprintf("<NewRemoteHost></NewRemoteHost>"+
+		"<NewExternalPort>%d</NewExternalPort>"+
+		"<NewProtocol>%s</NewProtocol>"+
+		"<NewInternalPort>%d</NewInternalPort>"+
+		"<NewInternalClient>%s</NewInternalClient>"+
+		"<NewEnabled>1</NewEnabled>"+
+		"<NewPortMappingDescription>%s</NewPortMappingDescription>"+
+		"<NewLeaseDuration>%d</NewLeaseDuration>",
+		external, strings.ToUpper(protocol), internal, n.local, escape(desc), int(lifetime/time.Second))
+	if err := n.call("AddPortMapping", args, nil); err != nil {
+		return 0, err
+	}
+	return external, nil
+}
+
+// DeletePortMapping removes the forward of the external port
+func (n *upnp) DeletePortMapping(protocol string, internal, external int) error {
+	args := fmt.Sprintf("<NewRemoteHost></NewRemoteHost>"+
+		"<NewExternalPort>%d</NewExternalPort>"+
+		"<NewProtocol>%s</NewProtocol>",
+		external, strings.ToUpper(protocol))
+	return n.call("DeletePortMapping", args, nil)
+}
+
+// call makes a SOAP request to the gateway and decodes the response into out, if it is not nil
+func (n *upnp) call(action, args string, out interface{}) error {
+	body := soapPrefix + `<u:` + action + ` xmlns:u="` + n.service + `">` + args + `</u:` + action + `>` + soapSuffix
+	req, err := http.NewRequest("POST", n.control, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+n.service+`#`+action+`"`)
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.New("UPnP " + action + " failed: " + res.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return xml.NewDecoder(res.Body).Decode(out)
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formats are the names accepted by Write, as given to an --output flag
+var Formats = []string{"text", "csv", "tsv"}
+
+// Table is a list-style command result, one value per column in each row
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// Options control how a table is written
+type Options struct {
+	// Format is one of Formats. Empty means text
+	Format string
+	// Columns selects and orders the columns to write by name. Empty means all, in table order
+	Columns []string
+	// NoHeader leaves out the line of column names, for piping into awk or cut
+	NoHeader bool
+}
+
+// Write encodes the table to w. Text is aligned for people to read, CSV follows RFC 4180, and text and TSV have tabs
+// and line breaks inside values replaced with spaces so every row stays on one line
+func Write(w io.Writer, t Table, o Options) (err error) {
+	var idx []int
+	if idx, err = t.indexes(o.Columns); err != nil {
+		return
+	}
+	pick := func(row []string) (out []string) {
+		for _, i := range idx {
+			if i < len(row) {
+				out = append(out, row[i])
+			} else {
+				out = append(out, "")
+			}
+		}
+		return
+	}
+	rows := make([][]string, 0, len(t.Rows)+1)
+	if !o.NoHeader {
+		rows = append(rows, pick(t.Columns))
+	}
+	for _, r := range t.Rows {
+		rows = append(rows, pick(r))
+	}
+	switch o.Format {
+	case "", "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, r := range rows {
+			fmt.Fprintln(tw, strings.Join(oneLine(r), "\t"))
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.WriteAll(rows)
+		return cw.Error()
+	case "tsv":
+		for _, r := range rows {
+			if _, err = io.WriteString(w, strings.Join(oneLine(r), "\t")+"\n"); err != nil {
+				return
+			}
+		}
+		return
+	}
+	return errors.New("unknown output format '" + o.Format + "', use one of " + strings.Join(Formats, ", "))
+}
+
+// indexes maps column names to their positions in the table
+func (t Table) indexes(names []string) (out []int, err error) {
+	if len(names) == 0 {
+		for i := range t.Columns {
+			out = append(out, i)
+		}
+		return
+	}
+	for _, n := range names {
+		found := false
+		for i, c := range t.Columns {
+			if strings.EqualFold(c, n) {
+				out, found = append(out, i), true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("no column named '" + n + "'")
+		}
+	}
+	return
+}
+
+var flatten = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+func oneLine(row []string) []string {
+	for i := range row {
+		row[i] = flatten.Replace(row[i])
+	}
+	return row
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package pager
+
+import "os"
+
+func height(out *os.File) int {
+	return 0
+}
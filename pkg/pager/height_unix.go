@@ -0,0 +1,22 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package pager
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func height(out *os.File) int {
+	var ws struct {
+		Row, Col, X, Y uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, out.Fd(), uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Row)
+}
@@ -0,0 +1,54 @@
+package pager
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/l0k1verloren/skele/pkg/tty"
+)
+
+// DefaultPager is used when $PAGER is not set
+const DefaultPager = "less"
+
+// defaultHeight is assumed when the terminal size cannot be found
+const defaultHeight = 24
+
+// Write sends text, such as long help or handler output, to out. When out is a terminal and the text is taller
+// than it, the text goes through $PAGER instead, unless noPager is set from --no-pager. If the pager cannot be
+// started the text is written directly
+func Write(out *os.File, text []byte, noPager bool) error {
+	if noPager || !tty.IsTerminal(out) || bytes.Count(text, []byte("\n")) < Height(out) {
+		_, err := out.Write(text)
+		return err
+	}
+	args := strings.Fields(os.Getenv("PAGER"))
+	if len(args) == 0 {
+		args = []string{DefaultPager}
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(text), out, os.Stderr
+	cmd.Env = os.Environ()
+	if _, set := os.LookupEnv("LESS"); !set {
+		// quit if it fits after all, keep colours and leave the text on screen afterwards, as git does
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		_, err = out.Write(text)
+		return err
+	}
+	return cmd.Wait()
+}
+
+// Height returns the number of rows of the terminal out is connected to, falling back to $LINES and then 24
+func Height(out *os.File) int {
+	if h := height(out); h > 0 {
+		return h
+	}
+	if h, err := strconv.Atoi(os.Getenv("LINES")); err == nil && h > 0 {
+		return h
+	}
+	return defaultHeight
+}
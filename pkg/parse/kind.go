@@ -0,0 +1,82 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Kind is a named parameter syntax registered by a package, such as a checkpoint written as <height>:<hash>.
+// Validate and Format are optional
+type Kind struct {
+	Name     string
+	Syntax   string
+	Help     string
+	Parse    func(in string) (interface{}, error)
+	Validate func(v interface{}) error
+	Format   func(v interface{}) string
+}
+
+var (
+	kindsMx sync.RWMutex
+	kinds   = map[string]Kind{}
+)
+
+// RegisterKind adds a parameter kind, usually from an init function. It panics if the kind has no name or parser,
+// or the name is already taken, as that is a programming error
+func RegisterKind(k Kind) {
+	if k.Name == "" || k.Parse == nil {
+		panic("parameter kind must have a name and a parser")
+	}
+	kindsMx.Lock()
+	defer kindsMx.Unlock()
+	if _, ok := kinds[k.Name]; ok {
+		panic("parameter kind '" + k.Name + "' registered twice")
+	}
+	kinds[k.Name] = k
+}
+
+// LookupKind returns the kind registered under name
+func LookupKind(name string) (k Kind, ok bool) {
+	kindsMx.RLock()
+	defer kindsMx.RUnlock()
+	k, ok = kinds[name]
+	return
+}
+
+// Kinds returns all registered kinds sorted by name, for generating help text
+func Kinds() (out []Kind) {
+	kindsMx.RLock()
+	defer kindsMx.RUnlock()
+	for _, k := range kinds {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return
+}
+
+// ToKind reads a value using the parser of the named kind and checks it with the kind's validator
+func ToKind(in, name string) (out interface{}, err error) {
+	k, ok := LookupKind(name)
+	if !ok {
+		return nil, errors.New("unknown parameter kind '" + name + "'")
+	}
+	if out, err = k.Parse(in); err != nil {
+		return nil, err
+	}
+	if k.Validate != nil {
+		if err = k.Validate(out); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// FormatKind writes a value back out in the syntax of the named kind, using fmt.Sprint if it has no formatter
+func FormatKind(v interface{}, name string) string {
+	if k, ok := LookupKind(name); ok && k.Format != nil {
+		return k.Format(v)
+	}
+	return fmt.Sprint(v)
+}
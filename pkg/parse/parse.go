@@ -4,6 +4,7 @@ import (
 	"encoding/base32"
 	"encoding/hex"
 	"errors"
+	"net"
 	"net/url"
 	"strconv"
 	"strings"
@@ -98,6 +99,106 @@ func Int(in string) (out T.Int, err error) {
 	return T.Int(i), err
 }
 
+// intUnits are the multipliers accepted as suffixes by IntUnits. Note that the kiB etc constants are the decimal ones
+var intUnits = map[string]int64{
+	"k":  kiB,
+	"m":  miB,
+	"g":  giB,
+	"ki": kB,
+	"mi": mB,
+	"gi": gB,
+}
+
+// IntUnits reads an integer that may carry a case insensitive k, m or g suffix for powers of 1000, or ki, mi or gi
+// for powers of 1024, so 200k is 200000 and 3mi is 3145728. The result must be between min and max inclusive
+func IntUnits(in string, min, max int64) (out T.Int, err error) {
+	num, unit := in, one
+	lower := strings.ToLower(in)
+	for suffix, u := range intUnits {
+		if strings.HasSuffix(lower, suffix) {
+			num, unit = in[:len(in)-len(suffix)], u
+			break
+		}
+	}
+	var i int64
+	if i, err = strconv.ParseInt(num, 10, 64); err != nil {
+		return
+	}
+	if i > 0 && i > (1<<63-1)/unit || i < 0 && i < -(1<<63-1)/unit {
+		return 0, errors.New("value '" + in + "' is out of range")
+	}
+	i *= unit
+	if i < min || i > max {
+		return 0, errors.New("value '" + in + "' must be between " +
+			strconv.FormatInt(min, 10) + " and " + strconv.FormatInt(max, 10))
+	}
+	return T.Int(i), nil
+}
+
+// IPNet reads a CIDR range such as 10.0.0.0/8, or a bare IPv4 or IPv6 address, which becomes a range holding only
+// that address
+func IPNet(in string) (out T.IPNet, err error) {
+	var n *net.IPNet
+	if _, n, err = net.ParseCIDR(in); err == nil {
+		return T.IPNet(n), nil
+	}
+	ip := net.ParseIP(in)
+	if ip == nil {
+		return nil, errors.New("'" + in + "' is not an IP address or CIDR range")
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return T.IPNet(&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}), nil
+}
+
+// IPNetList reads a list of IP addresses and CIDR ranges separated by commas or spaces, as used for whitelists
+func IPNetList(in string) (out T.IPNetList, err error) {
+	for _, s := range strings.FieldsFunc(in, func(r rune) bool { return r == ',' || r == ' ' }) {
+		var n T.IPNet
+		if n, err = IPNet(s); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return
+}
+
+// Port reads a TCP or UDP port number, which must be between min and max inclusive, such as 1024 and 65535 for
+// ports that do not need privileges
+func Port(in string, min, max uint16) (out T.Port, err error) {
+	var p uint64
+	if p, err = strconv.ParseUint(strings.TrimPrefix(in, ":"), 10, 16); err != nil {
+		return 0, errors.New("'" + in + "' is not a port number")
+	}
+	if uint16(p) < min || uint16(p) > max {
+		return 0, errors.New("port " + in + " must be between " +
+			strconv.Itoa(int(min)) + " and " + strconv.Itoa(int(max)))
+	}
+	return T.Port(p), nil
+}
+
+// PortRange reads a range of ports written as low-high, or a single port as a range of one, with both ends between
+// min and max inclusive
+func PortRange(in string, min, max uint16) (out T.PortRange, err error) {
+	lo, hi := in, in
+	if i := strings.IndexByte(in, '-'); i >= 0 {
+		lo, hi = in[:i], in[i+1:]
+	}
+	var l, h T.Port
+	if l, err = Port(strings.TrimSpace(lo), min, max); err != nil {
+		return
+	}
+	if h, err = Port(strings.TrimSpace(hi), min, max); err != nil {
+		return
+	}
+	if l > h {
+		return out, errors.New("port range " + in + " ends before it starts")
+	}
+	return T.PortRange{uint16(l), uint16(h)}, nil
+}
+
 // Size accepts a string and returns a value representing bytes, using the following annotations:
 // kKmMgGtTpP single letter for power of 2 based size
 // kb/mb/gb/tb/pb case insensitive ^2 based size
@@ -256,6 +357,26 @@ func ToType(in string, I interface{}) (out interface{}, err error) {
 		if o, err = Hex(in); err == nil {
 			out = o
 		}
+	case T.IPNet:
+		var o T.IPNet
+		if o, err = IPNet(in); err == nil {
+			out = o
+		}
+	case T.IPNetList:
+		var o T.IPNetList
+		if o, err = IPNetList(in); err == nil {
+			out = o
+		}
+	case T.Port:
+		var o T.Port
+		if o, err = Port(in, 0, 65535); err == nil {
+			out = o
+		}
+	case T.PortRange:
+		var o T.PortRange
+		if o, err = PortRange(in, 0, 65535); err == nil {
+			out = o
+		}
 	default:
 		err = errors.New("unhandled type")
 	}
@@ -0,0 +1,118 @@
+package periodic
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Job runs a function at an interval, such as rebroadcasting transactions or pruning, until it is stopped
+type Job struct {
+	interval time.Duration
+	// Jitter is the largest random delay added to each interval, so many jobs started together spread out
+	Jitter time.Duration
+	// OnError is called with errors returned by the function, if set
+	OnError func(error)
+
+	fn      func() error
+	mx      sync.Mutex
+	running bool
+	skipped int
+	wg      sync.WaitGroup
+	stop    chan struct{}
+	trigger chan struct{}
+}
+
+// New returns a job that runs fn every interval once started
+func New(interval time.Duration, fn func() error) *Job {
+	return &Job{
+		interval: interval,
+		fn:       fn,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Start begins running the job in the background. The first run happens after one interval
+func (j *Job) Start() {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	if j.stop != nil {
+		return
+	}
+	j.stop = make(chan struct{})
+	j.wg.Add(1)
+	go j.loop(j.stop)
+}
+
+// Trigger runs the job now, without waiting for the interval, unless a run is already in progress
+func (j *Job) Trigger() {
+	select {
+	case j.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Skipped is how many runs were skipped because the previous one had not finished
+func (j *Job) Skipped() int {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	return j.skipped
+}
+
+// Stop ends the schedule and waits for a run in progress to finish, for use at shutdown
+func (j *Job) Stop() {
+	j.mx.Lock()
+	stop := j.stop
+	j.stop = nil
+	j.mx.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+	j.wg.Wait()
+}
+
+func (j *Job) loop(stop chan struct{}) {
+	defer j.wg.Done()
+	t := time.NewTimer(j.next())
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			t.Reset(j.next())
+		case <-j.trigger:
+		case <-stop:
+			return
+		}
+		j.run()
+	}
+}
+
+// run starts the function in its own goroutine so a slow run does not delay the schedule, skipping it if the
+// previous run is still going
+func (j *Job) run() {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	if j.running {
+		j.skipped++
+		return
+	}
+	j.running = true
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		err := j.fn()
+		if err != nil && j.OnError != nil {
+			j.OnError(err)
+		}
+		j.mx.Lock()
+		j.running = false
+		j.mx.Unlock()
+	}()
+}
+
+func (j *Job) next() time.Duration {
+	if j.Jitter <= 0 {
+		return j.interval
+	}
+	return j.interval + time.Duration(rand.Int63n(int64(j.Jitter)))
+}
@@ -0,0 +1,181 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/l0k1verloren/skele/pkg/tty"
+)
+
+const (
+	barWidth    = 40
+	redrawEvery = 100 * time.Millisecond
+	logEvery    = 5 * time.Second
+)
+
+var spinFrames = []string{"|", "/", "-", "\\"}
+
+// Progress is the interface for reporting the advancement of a long running command.
+// A total of zero or less means the amount of work is unknown and a spinner is shown instead of a bar
+type Progress interface {
+	Start(label string, total int64)
+	Add(n int64)
+	Set(n int64)
+	Done()
+}
+
+// New returns a Progress writing to w. On a terminal it draws a bar or spinner, otherwise it writes
+// periodic log lines. If quiet is set nothing is written at all
+func New(w io.Writer, quiet bool) Progress {
+	switch {
+	case quiet:
+		return nop{}
+	case tty.IsTerminal(w):
+		return &bar{w: w}
+	default:
+		return &logger{w: w}
+	}
+}
+
+type nop struct{}
+
+func (nop) Start(string, int64) {}
+func (nop) Add(int64)           {}
+func (nop) Set(int64)           {}
+func (nop) Done()               {}
+
+// bar draws a bar or spinner in place on a terminal
+type bar struct {
+	sync.Mutex
+	w       io.Writer
+	label   string
+	total   int64
+	current int64
+	frame   int
+	last    time.Time
+}
+
+// Start resets the bar with a new label and total
+func (b *bar) Start(label string, total int64) {
+	b.Lock()
+	defer b.Unlock()
+	b.label, b.total, b.current, b.frame = label, total, 0, 0
+	b.draw(true)
+}
+
+// Add advances the bar by n units
+func (b *bar) Add(n int64) {
+	b.Lock()
+	defer b.Unlock()
+	b.current += n
+	b.draw(false)
+}
+
+// Set moves the bar to position n
+func (b *bar) Set(n int64) {
+	b.Lock()
+	defer b.Unlock()
+	b.current = n
+	b.draw(false)
+}
+
+// Done draws the final state and moves to a new line
+func (b *bar) Done() {
+	b.Lock()
+	defer b.Unlock()
+	if b.total > 0 {
+		b.current = b.total
+	}
+	b.draw(true)
+	fmt.Fprintln(b.w)
+}
+
+func (b *bar) draw(force bool) {
+	now := time.Now()
+	if !force && now.Sub(b.last) < redrawEvery {
+		return
+	}
+	b.last = now
+	if b.total <= 0 {
+		b.frame = (b.frame + 1) % len(spinFrames)
+		fmt.Fprintf(b.w, "\r%s %s %d", b.label, spinFrames[b.frame], b.current)
+		return
+	}
+	filled := int(clamp(b.current, b.total) * barWidth / b.total)
+	fmt.Fprintf(b.w, "\r%s [%s%s] %3d%%", b.label,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled),
+		percent(b.current, b.total))
+}
+
+// logger writes a line for every ten percent of progress, or every few seconds when the total is unknown
+type logger struct {
+	sync.Mutex
+	w       io.Writer
+	label   string
+	total   int64
+	current int64
+	step    int64
+	last    time.Time
+}
+
+// Start prints the label and resets the counters
+func (l *logger) Start(label string, total int64) {
+	l.Lock()
+	defer l.Unlock()
+	l.label, l.total, l.current, l.step, l.last = label, total, 0, 0, time.Now()
+	fmt.Fprintf(l.w, "%s: started\n", l.label)
+}
+
+// Add advances the counter by n units
+func (l *logger) Add(n int64) {
+	l.Lock()
+	defer l.Unlock()
+	l.current += n
+	l.report()
+}
+
+// Set moves the counter to position n
+func (l *logger) Set(n int64) {
+	l.Lock()
+	defer l.Unlock()
+	l.current = n
+	l.report()
+}
+
+// Done prints the final line
+func (l *logger) Done() {
+	l.Lock()
+	defer l.Unlock()
+	fmt.Fprintf(l.w, "%s: done (%d)\n", l.label, l.current)
+}
+
+func (l *logger) report() {
+	if l.total <= 0 {
+		if now := time.Now(); now.Sub(l.last) >= logEvery {
+			l.last = now
+			fmt.Fprintf(l.w, "%s: %d\n", l.label, l.current)
+		}
+		return
+	}
+	if step := percent(l.current, l.total) / 10; step > l.step {
+		l.step = step
+		fmt.Fprintf(l.w, "%s: %d%% (%d/%d)\n", l.label, step*10, l.current, l.total)
+	}
+}
+
+func clamp(n, total int64) int64 {
+	switch {
+	case n < 0:
+		return 0
+	case n > total:
+		return total
+	}
+	return n
+}
+
+func percent(n, total int64) int64 {
+	return clamp(n, total) * 100 / total
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/go-socks/socks"
+)
+
+// Config is the proxy parameter bundle, as a daemon reading it from its tree would fill it in
+type Config struct {
+	Proxy          string
+	ProxyUser      string
+	ProxyPass      string
+	OnionProxy     string
+	OnionProxyUser string
+	OnionProxyPass string
+	NoOnion        bool
+	// Tor is set when Proxy is a Tor proxy, so names are resolved through it instead of leaking to local DNS
+	Tor bool
+	// TorIsolation uses fresh random credentials for every connection so Tor builds a separate circuit for each
+	TorIsolation bool
+	// Timeout limits how long connecting, through a proxy or directly, and Tor lookups may take. Zero means
+	// DefaultTimeout
+	Timeout time.Duration
+}
+
+// DefaultTimeout is the connection timeout used when Config.Timeout is not set
+const DefaultTimeout = 30 * time.Second
+
+// DialFunc opens a connection the way net.Dial does
+type DialFunc func(network, addr string) (net.Conn, error)
+
+// LookupFunc resolves a host name to its addresses
+type LookupFunc func(host string) ([]net.IP, error)
+
+// ErrNoOnion is returned when dialing a .onion address with onion connections disabled
+var ErrNoOnion = errors.New("tor has been disabled")
+
+// Validate checks the bundle for combinations that cannot work
+func (c Config) Validate() error {
+	if c.TorIsolation && c.Proxy == "" && c.OnionProxy == "" {
+		return errors.New("tor stream isolation requires a proxy or onion proxy to be set")
+	}
+	if c.Tor && c.Proxy == "" {
+		return errors.New("resolving names through tor requires the proxy to be set")
+	}
+	if c.NoOnion && c.OnionProxy != "" {
+		return errors.New("the onion proxy cannot be set when onion connections are disabled")
+	}
+	for _, a := range []string{c.Proxy, c.OnionProxy} {
+		if a == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(a); err != nil {
+			return errors.New("proxy address '" + a + "' is invalid: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// Dial returns a dial function that routes .onion addresses through the onion proxy, or the main proxy when no
+// onion proxy is set, and everything else through the main proxy or directly
+func (c Config) Dial() DialFunc {
+	timeout := c.timeout()
+	dial := func(network, addr string) (net.Conn, error) {
+		return net.DialTimeout(network, addr, timeout)
+	}
+	if c.Proxy != "" {
+		dial = dialer(c.proxy(), timeout)
+	}
+	onion := dial
+	switch {
+	case c.NoOnion:
+		onion = func(string, string) (net.Conn, error) {
+			return nil, ErrNoOnion
+		}
+	case c.OnionProxy != "":
+		onion = dialer(&socks.Proxy{
+			Addr:         c.OnionProxy,
+			Username:     c.OnionProxyUser,
+			Password:     c.OnionProxyPass,
+			TorIsolation: c.TorIsolation,
+		}, timeout)
+	}
+	return func(network, addr string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(addr); err == nil && strings.HasSuffix(host, ".onion") {
+			return onion(network, addr)
+		}
+		return dial(network, addr)
+	}
+}
+
+// Lookup returns a resolver that asks Tor when the proxy is a Tor proxy, using the proxy's credentials, and the
+// system resolver otherwise
+func (c Config) Lookup() LookupFunc {
+	if c.Tor && c.Proxy != "" {
+		p, timeout := c.proxy(), c.timeout()
+		return func(host string) ([]net.IP, error) {
+			return TorLookupIP(host, p, timeout)
+		}
+	}
+	return net.LookupIP
+}
+
+func (c Config) proxy() *socks.Proxy {
+	return &socks.Proxy{
+		Addr:         c.Proxy,
+		Username:     c.ProxyUser,
+		Password:     c.ProxyPass,
+		TorIsolation: c.TorIsolation,
+	}
+}
+
+func dialer(p *socks.Proxy, timeout time.Duration) DialFunc {
+	return func(network, addr string) (net.Conn, error) {
+		return p.DialTimeout(network, addr, timeout)
+	}
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return c.Timeout
+}
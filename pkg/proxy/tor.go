@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/btcsuite/go-socks/socks"
+)
+
+const (
+	socksVersion = 5
+	socksNoAuth  = 0
+	// socksUserPass is username and password authentication, RFC 1929, which has its own version number
+	socksUserPass        = 2
+	socksUserPassVersion = 1
+	// torResolve is Tor's extension to the SOCKS5 commands for resolving a name without connecting
+	torResolve = 0xf0
+	atypIPv4   = 1
+	atypDomain = 3
+	atypIPv6   = 4
+)
+
+var socksErrors = map[byte]string{
+	1: "general failure",
+	2: "connection not allowed",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// TorLookupIP resolves host through the Tor SOCKS proxy p, so the name is never sent to local DNS. The proxy's
+// credentials are used if it has them, or fresh random ones with TorIsolation set, and the whole lookup must finish
+// within timeout
+func TorLookupIP(host string, p *socks.Proxy, timeout time.Duration) (out []net.IP, err error) {
+	if len(host) > 255 {
+		return nil, errors.New("host name too long")
+	}
+	var c net.Conn
+	if c, err = net.DialTimeout("tcp", p.Addr, timeout); err != nil {
+		return
+	}
+	defer c.Close()
+	if err = c.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return
+	}
+	if err = authenticate(c, p); err != nil {
+		return
+	}
+	req := append([]byte{socksVersion, torResolve, 0, atypDomain, byte(len(host))}, host...)
+	req = append(req, 0, 0)
+	if _, err = c.Write(req); err != nil {
+		return
+	}
+	buf := make([]byte, 4)
+	if _, err = io.ReadFull(c, buf); err != nil {
+		return
+	}
+	if buf[1] != 0 {
+		if msg, ok := socksErrors[buf[1]]; ok {
+			return nil, errors.New("tor lookup of " + host + " failed: " + msg)
+		}
+		return nil, errors.New("tor lookup of " + host + " failed")
+	}
+	var ip net.IP
+	switch buf[3] {
+	case atypIPv4:
+		ip = make(net.IP, net.IPv4len)
+	case atypIPv6:
+		ip = make(net.IP, net.IPv6len)
+	default:
+		return nil, errors.New("tor proxy returned an unexpected address type")
+	}
+	if _, err = io.ReadFull(c, ip); err != nil {
+		return
+	}
+	// the reply ends with a port, which means nothing for a lookup
+	if _, err = io.ReadFull(c, make([]byte, 2)); err != nil {
+		return
+	}
+	return []net.IP{ip}, nil
+}
+
+// authenticate negotiates the SOCKS5 authentication method, logging in with a username and password when the proxy
+// has them
+func authenticate(c net.Conn, p *socks.Proxy) (err error) {
+	user, pass := p.Username, p.Password
+	if p.TorIsolation {
+		b := make([]byte, 16)
+		if _, err = rand.Read(b); err != nil {
+			return
+		}
+		user, pass = hex.EncodeToString(b[:8]), hex.EncodeToString(b[8:])
+	}
+	method := byte(socksNoAuth)
+	if user != "" || pass != "" {
+		method = socksUserPass
+	}
+	if len(user) > 255 || len(pass) > 255 {
+		return errors.New("proxy username and password must be at most 255 bytes")
+	}
+	if _, err = c.Write([]byte{socksVersion, 1, method}); err != nil {
+		return
+	}
+	buf := make([]byte, 2)
+	if _, err = io.ReadFull(c, buf); err != nil {
+		return
+	}
+	if buf[0] != socksVersion || buf[1] != method {
+		return errors.New("tor proxy refused the authentication method")
+	}
+	if method == socksNoAuth {
+		return
+	}
+	req := append([]byte{socksUserPassVersion, byte(len(user))}, user...)
+	req = append(append(req, byte(len(pass))), pass...)
+	if _, err = c.Write(req); err != nil {
+		return
+	}
+	if _, err = io.ReadFull(c, buf); err != nil {
+		return
+	}
+	if buf[1] != 0 {
+		return errors.New("tor proxy rejected the username and password")
+	}
+	return
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package status
+
+import (
+	"errors"
+	"syscall"
+)
+
+func refused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
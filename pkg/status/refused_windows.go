@@ -0,0 +1,12 @@
+package status
+
+import (
+	"errors"
+	"syscall"
+)
+
+const errWSAConnRefused syscall.Errno = 10061
+
+func refused(err error) bool {
+	return errors.Is(err, errWSAConnRefused) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,147 @@
+package status
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"time"
+)
+
+// SocketName is the control socket created in a daemon's data directory
+const SocketName = "control.sock"
+
+const queryTimeout = 5 * time.Second
+
+// Status is what a daemon reports about itself to `appname status`
+type Status struct {
+	Name       string            `json:"name"`
+	Version    string            `json:"version"`
+	PID        int               `json:"pid"`
+	Started    time.Time         `json:"started"`
+	ConfigHash string            `json:"config_hash"`
+	Values     map[string]string `json:"values"`
+}
+
+// Uptime is how long the daemon has been running
+func (s Status) Uptime() time.Duration {
+	return time.Since(s.Started).Truncate(time.Second)
+}
+
+// Server answers status queries on a control socket
+type Server struct {
+	l    net.Listener
+	path string
+}
+
+// Serve listens on the socket at path, replacing a stale one, and answers each connection with the Status
+// returned by fn. Live parameter values should be read inside fn so they are current. The socket is only readable
+// by the owner, as the values can include credentials. It is an error if a daemon is already answering on path
+func Serve(path string, fn func() Status) (s *Server, err error) {
+	if err = removeStale(path); err != nil {
+		return
+	}
+	var l net.Listener
+	if l, err = net.Listen("unix", path); err != nil {
+		return
+	}
+	if err = os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return
+	}
+	s = &Server{l, path}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				c.SetDeadline(time.Now().Add(queryTimeout))
+				json.NewEncoder(c).Encode(fn())
+			}()
+		}
+	}()
+	return
+}
+
+// removeStale removes the socket at path if it is left over from a daemon that has exited, which shows as the
+// connection being refused. Anything at path that is not a socket is left alone
+func removeStale(path string) error {
+	fi, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case fi.Mode()&os.ModeSocket == 0:
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	c, err := net.DialTimeout("unix", path, queryTimeout)
+	switch {
+	case err == nil:
+		c.Close()
+		return fmt.Errorf("another process is already answering on %s", path)
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case refused(err):
+		return os.Remove(path)
+	}
+	return err
+}
+
+// Close stops answering queries and removes the socket
+func (s *Server) Close() error {
+	defer os.Remove(s.path)
+	return s.l.Close()
+}
+
+// Query asks the daemon listening on the socket at path for its status
+func Query(path string) (s Status, err error) {
+	var c net.Conn
+	if c, err = net.DialTimeout("unix", path, queryTimeout); err != nil {
+		return s, fmt.Errorf("daemon is not running or not reachable: %v", err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(queryTimeout))
+	err = json.NewDecoder(c).Decode(&s)
+	return
+}
+
+// Hash returns a short digest of config values, so two status reports show whether the config changed
+func Hash(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, values[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Print writes a status report for people to read
+func Print(w io.Writer, s Status) (err error) {
+	fmt.Fprintf(w, "%s %s (pid %d)\n", s.Name, s.Version, s.PID)
+	fmt.Fprintf(w, "uptime:  %v\n", s.Uptime())
+	fmt.Fprintf(w, "config:  %s\n", s.ConfigHash)
+	keys := make([]string, 0, len(s.Values))
+	for k := range s.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err = fmt.Fprintf(w, "  %s = %s\n", k, s.Values[k]); err != nil {
+			return
+		}
+	}
+	return
+}
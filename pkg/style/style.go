@@ -0,0 +1,120 @@
+package style
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/l0k1verloren/skele/pkg/tty"
+)
+
+// Role is the purpose of a piece of text, which a Theme maps to a colour
+type Role int
+
+// The roles used by help, errors and handler output
+const (
+	Plain Role = iota
+	Heading
+	Command
+	Key
+	Value
+	Error
+	Warn
+	Success
+	Faint
+)
+
+// Theme maps roles to ANSI SGR parameter strings, such as "1;31" for bold red
+type Theme map[Role]string
+
+// Themes are the named themes that can be selected with Use
+var Themes = map[string]Theme{
+	"default": {
+		Heading: "1",
+		Command: "1;36",
+		Key:     "33",
+		Value:   "32",
+		Error:   "1;31",
+		Warn:    "35",
+		Success: "32",
+		Faint:   "2",
+	},
+	"light": {
+		Heading: "1;34",
+		Command: "34",
+		Key:     "35",
+		Value:   "32",
+		Error:   "31",
+		Warn:    "33",
+		Success: "32",
+		Faint:   "90",
+	},
+	"mono": {
+		Heading: "1",
+		Command: "1",
+		Error:   "1",
+		Warn:    "4",
+		Faint:   "2",
+	},
+}
+
+// Style applies a theme to text destined for a writer, or passes it through untouched when colour is off
+type Style struct {
+	theme Theme
+	on    bool
+}
+
+// New returns a Style for output going to w. Colour is only enabled when w is a terminal, noColor
+// (from --no-color) is false and the NO_COLOR environment variable is empty or unset
+func New(w io.Writer, noColor bool) *Style {
+	return &Style{
+		theme: Themes["default"],
+		on:    Enabled(w, noColor),
+	}
+}
+
+// Enabled reports whether colour output should be used on w
+func Enabled(w io.Writer, noColor bool) bool {
+	if os.Getenv("NO_COLOR") != "" || noColor {
+		return false
+	}
+	return tty.IsTerminal(w)
+}
+
+// Use switches to the named theme, returning an error if there is no such theme
+func (s *Style) Use(name string) error {
+	t, ok := Themes[name]
+	if !ok {
+		return fmt.Errorf("no theme named '%s'", name)
+	}
+	s.theme = t
+	return nil
+}
+
+// On returns true if colour codes are being emitted
+func (s *Style) On() bool {
+	return s.on
+}
+
+// Sprint formats the arguments like fmt.Sprint and wraps the result in the colour for the role
+func (s *Style) Sprint(r Role, a ...interface{}) string {
+	return s.wrap(r, fmt.Sprint(a...))
+}
+
+// Sprintf formats like fmt.Sprintf and wraps the result in the colour for the role
+func (s *Style) Sprintf(r Role, format string, a ...interface{}) string {
+	return s.wrap(r, fmt.Sprintf(format, a...))
+}
+
+// Fprintf writes formatted text in the colour for the role to w
+func (s *Style) Fprintf(w io.Writer, r Role, format string, a ...interface{}) (int, error) {
+	return io.WriteString(w, s.Sprintf(r, format, a...))
+}
+
+func (s *Style) wrap(r Role, text string) string {
+	code, ok := s.theme[r]
+	if !s.on || !ok || code == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
@@ -0,0 +1,107 @@
+package subproc
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/l0k1verloren/skele/pkg/fail"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Command is the handler of a node that runs an external binary, such as a gui launched from the pod tree
+type Command struct {
+	Path string
+	// Args are the arguments, where ${name} is replaced by the value of the named parameter
+	Args []string
+	// EnvPrefix, if set, exports every parameter to the child's environment as PREFIX_NAME in upper case, with
+	// path separators turned into underscores
+	EnvPrefix string
+}
+
+// New returns a command running the binary at path with the given argument templates
+func New(path string, args ...string) *Command {
+	return &Command{Path: path, Args: args}
+}
+
+// Argv expands the argument templates with the parameter values. Unknown names expand to nothing, and a $ not
+// followed by { is left alone so arguments meant for a shell keep their variables
+func (c *Command) Argv(values map[string]string) (out []string) {
+	for _, a := range c.Args {
+		out = append(out, expand(a, values))
+	}
+	return
+}
+
+func expand(s string, values map[string]string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		b.WriteString(values[s[i+2:i+j]])
+		s = s[i+j+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// Env returns the parameter values as environment variables, sorted, or nothing when there is no prefix
+func (c *Command) Env(values map[string]string) (out []string) {
+	if c.EnvPrefix == "" {
+		return
+	}
+	r := strings.NewReplacer("/", "_", "-", "_", ".", "_")
+	for k, v := range values {
+		out = append(out, strings.ToUpper(c.EnvPrefix+"_"+r.Replace(k))+"="+v)
+	}
+	sort.Strings(out)
+	return
+}
+
+// Run starts the binary with stdin, stdout and stderr wired through, forwards interrupt and terminate signals to
+// it while it runs, and returns an error carrying its exit code if it fails. When stdin is a terminal an interrupt
+// is not forwarded, as the child is in the terminal's foreground process group and gets Ctrl-C itself, and a
+// second one would look like the user pressing it twice
+func (c *Command) Run(values map[string]string) (err error) {
+	interactive := terminal.IsTerminal(int(os.Stdin.Fd()))
+	cmd := exec.Command(c.Path, c.Argv(values)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), c.Env(values)...)
+	if err = cmd.Start(); err != nil {
+		return fail.Handler(err)
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case s := <-sig:
+				if s != os.Interrupt || !interactive {
+					cmd.Process.Signal(s)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	err = cmd.Wait()
+	signal.Stop(sig)
+	close(done)
+	if ee, ok := err.(*exec.ExitError); ok {
+		if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.ExitStatus() > 0 {
+			return fail.New(ws.ExitStatus(), err)
+		}
+	}
+	return fail.Handler(err)
+}
@@ -0,0 +1,128 @@
+package telemetry
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExpvarName is the expvar variable holding the command counters, served at /debug/vars by the expvar handler
+const ExpvarName = "skele.commands"
+
+// ParseErrorsName is the expvar variable counting command lines that failed to parse, by the path of the command
+// being read when the error was found
+const ParseErrorsName = "skele.parse_errors"
+
+var (
+	publishOnce sync.Once
+	parseErrors *expvar.Map
+	expvarOnce  sync.Once
+	expvarHook  *counters
+)
+
+// counters is a Hook publishing per command invocation counts, error counts and last run times through expvar
+type counters struct {
+	sync.Mutex
+	m *expvar.Map
+}
+
+// publish creates the expvar variables, which can only be done once per process
+func publish() {
+	publishOnce.Do(func() {
+		expvarHook = &counters{m: expvar.NewMap(ExpvarName)}
+		parseErrors = expvar.NewMap(ParseErrorsName)
+	})
+}
+
+// Expvar returns the hook that publishes command counters through expvar, registering it the first time so a
+// daemon only has to call this once at startup
+func Expvar() Hook {
+	publish()
+	expvarOnce.Do(func() {
+		Register(expvarHook)
+	})
+	return expvarHook
+}
+
+// ParseError counts a command line that failed to parse, where path is the command being read when the error was
+// found, or the root for an error before any command was recognised. Parse errors never reach a handler, so the
+// parser reports them here rather than through a Hook
+func ParseError(path string) {
+	publish()
+	parseErrors.Add(path, 1)
+}
+
+// Dispatched updates the counters for the command
+func (c *counters) Dispatched(d Dispatch) {
+	c.Lock()
+	defer c.Unlock()
+	cmd, ok := c.m.Get(d.Path).(*expvar.Map)
+	if !ok {
+		cmd = new(expvar.Map).Init()
+		c.m.Set(d.Path, cmd)
+	}
+	cmd.Add("invocations", 1)
+	if !d.OK() {
+		cmd.Add("errors", 1)
+	}
+	last := new(expvar.String)
+	last.Set(time.Now().UTC().Format(time.RFC3339))
+	cmd.Set("last_run", last)
+}
+
+// WriteMetrics prints the command counters as a table, which is what a metrics subcommand shows
+func WriteMetrics(w io.Writer) (err error) {
+	const row = "%-32s %11s %7s %12s  %s\n"
+	if _, err = fmt.Fprintf(w, row, "COMMAND", "INVOCATIONS", "ERRORS", "PARSE ERRORS", "LAST RUN"); err != nil {
+		return
+	}
+	commands, _ := expvar.Get(ExpvarName).(*expvar.Map)
+	parse, _ := expvar.Get(ParseErrorsName).(*expvar.Map)
+	var paths []string
+	seen := make(map[string]bool)
+	for _, m := range []*expvar.Map{commands, parse} {
+		if m == nil {
+			continue
+		}
+		m.Do(func(kv expvar.KeyValue) {
+			if !seen[kv.Key] {
+				seen[kv.Key] = true
+				paths = append(paths, kv.Key)
+			}
+		})
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		cmd := new(expvar.Map).Init()
+		if commands != nil {
+			if c, ok := commands.Get(path).(*expvar.Map); ok {
+				cmd = c
+			}
+		}
+		parseErrs := "0"
+		if parse != nil {
+			if v := parse.Get(path); v != nil {
+				parseErrs = v.String()
+			}
+		}
+		if _, err = fmt.Fprintf(w, row, path, get(cmd, "invocations", "0"), get(cmd, "errors", "0"),
+			parseErrs, get(cmd, "last_run", "-")); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func get(m *expvar.Map, key, missing string) string {
+	switch v := m.Get(key).(type) {
+	case *expvar.String:
+		return v.Value()
+	case nil:
+		return missing
+	default:
+		return v.String()
+	}
+}
@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/l0k1verloren/skele/pkg/fail"
+)
+
+// Dispatch describes one run of a command handler
+type Dispatch struct {
+	Path     string
+	Duration time.Duration
+	Code     int
+	Err      error
+}
+
+// OK returns true if the command completed without error
+func (d Dispatch) OK() bool {
+	return d.Err == nil
+}
+
+// Hook receives a Dispatch after every command runs. Implementations must be safe for concurrent use
+// and should return quickly, as they run on the dispatching goroutine
+type Hook interface {
+	Dispatched(Dispatch)
+}
+
+// HookFunc lets an ordinary function be used as a Hook
+type HookFunc func(Dispatch)
+
+// Dispatched calls the function
+func (f HookFunc) Dispatched(d Dispatch) {
+	f(d)
+}
+
+var (
+	mx    sync.RWMutex
+	hooks []Hook
+)
+
+// Register adds a hook to be called on each dispatch. By default there are none
+func Register(h Hook) {
+	mx.Lock()
+	defer mx.Unlock()
+	hooks = append(hooks, h)
+}
+
+// Reset removes all registered hooks
+func Reset() {
+	mx.Lock()
+	defer mx.Unlock()
+	hooks = nil
+}
+
+// Observe runs fn as the handler for the command at path and reports it to the registered hooks
+func Observe(path string, fn func() error) (err error) {
+	start := time.Now()
+	err = fn()
+	d := Dispatch{
+		Path:     path,
+		Duration: time.Since(start),
+		Code:     fail.Code(err),
+		Err:      err,
+	}
+	mx.RLock()
+	defer mx.RUnlock()
+	for _, h := range hooks {
+		h.Dispatched(d)
+	}
+	return
+}
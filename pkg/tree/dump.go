@@ -0,0 +1,37 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/l0k1verloren/skele/pkg/T"
+)
+
+// Dump writes an indented listing of a command tree, one node per line with its type, value and description.
+// The format is stable so it can be compared against golden files in tests
+func Dump(w io.Writer, root T.Cmd) error {
+	return dump(w, root, 0)
+}
+
+func dump(w io.Writer, c T.Cmd, depth int) (err error) {
+	line := strings.Repeat("  ", depth) + c.Name()
+	if t := c.Type(); t != "" {
+		line += " <" + t + ">"
+	}
+	if d := c.Data(); d != nil {
+		line += fmt.Sprintf(" = %v", d)
+	}
+	if desc := c.Description(); desc != "" {
+		line += " - " + desc
+	}
+	if _, err = fmt.Fprintln(w, line); err != nil {
+		return
+	}
+	for _, child := range c.List() {
+		if err = dump(w, child, depth+1); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,143 @@
+package tree
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/l0k1verloren/skele/pkg/T"
+	"github.com/l0k1verloren/skele/pkg/fail"
+	"github.com/l0k1verloren/skele/pkg/parse"
+)
+
+// listener is a range of ports a parameter asks the application to listen on
+type listener struct {
+	path   string
+	host   string
+	lo, hi uint16
+}
+
+// PortConflicts finds parameters that would make the application listen on the same port twice, reporting the
+// names of both parameters for every clash. Only the port, port range and address parameters whose paths are given
+// in listen are checked, as other addresses, like the target of rpcconnect, are ones the application connects to.
+// Port ranges clash when they overlap. A host that is empty or unspecified, like 0.0.0.0, clashes with every other
+// host on the same port
+func PortConflicts(root T.Cmd, listen ...string) error {
+	want := make(map[string]bool, len(listen))
+	for _, p := range listen {
+		want[p] = true
+	}
+	var found []listener
+	collectListeners(root, want, &found)
+	var problems fail.Multi
+	for _, p := range listen {
+		if want[p] {
+			problems.Add(fmt.Errorf("no port or address parameter at '%s'", p))
+		}
+	}
+	for i, l := range found {
+		for _, other := range found[:i] {
+			lo, hi := max16(l.lo, other.lo), min16(l.hi, other.hi)
+			if lo <= hi && sameHost(l.host, other.host) {
+				problems.Add(fmt.Errorf("'%s' and '%s' both listen on %s", other.path, l.path, ports(lo, hi)))
+			}
+		}
+	}
+	return fail.Validation(problems.Err())
+}
+
+// collectListeners gathers the listen parameters named in want, removing each one found from it
+func collectListeners(c T.Cmd, want map[string]bool, found *[]listener) {
+	if want[c.Path()] {
+		if l, ok := listenOn(c); ok {
+			delete(want, c.Path())
+			if l.hi != 0 {
+				*found = append(*found, l)
+			}
+		}
+	}
+	for _, child := range c.List() {
+		collectListeners(child, want, found)
+	}
+}
+
+// listenOn reads the ports a parameter holds. A port of 0, or an unset value, asks for no fixed port and gives a
+// listener with hi 0. ok is false if c is not a port, port range or address parameter
+func listenOn(c T.Cmd) (l listener, ok bool) {
+	l.path = c.Path()
+	d := c.Data()
+	switch c.Type() {
+	case T.PORT.Label, T.PORTRANGE.Label:
+		switch v := d.(type) {
+		case nil:
+		case T.Port:
+			l.lo, l.hi = uint16(v), uint16(v)
+		case T.PortRange:
+			l.lo, l.hi = v[0], v[1]
+		default:
+			if r, err := parse.PortRange(fmt.Sprint(v), 0, 65535); err == nil {
+				l.lo, l.hi = r[0], r[1]
+			}
+		}
+	case T.ADDRESS.Label:
+		if d == nil {
+			break
+		}
+		v := fmt.Sprint(d)
+		if u, err := url.Parse(v); err == nil && u.Host != "" {
+			v = u.Host
+		}
+		if host, port, err := net.SplitHostPort(v); err == nil {
+			if p, err := strconv.ParseUint(port, 10, 16); err == nil {
+				l.host, l.lo, l.hi = host, uint16(p), uint16(p)
+			}
+		}
+	default:
+		return l, false
+	}
+	if l.lo == 0 {
+		l.hi = 0
+	}
+	return l, true
+}
+
+func ports(lo, hi uint16) string {
+	if lo == hi {
+		return "port " + strconv.Itoa(int(lo))
+	}
+	return "ports " + strconv.Itoa(int(lo)) + "-" + strconv.Itoa(int(hi))
+}
+
+func min16(a, b uint16) uint16 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max16(a, b uint16) uint16 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func sameHost(a, b string) bool {
+	if wildcard(a) || wildcard(b) {
+		return true
+	}
+	if ipa, ipb := net.ParseIP(a), net.ParseIP(b); ipa != nil && ipb != nil {
+		return ipa.Equal(ipb)
+	}
+	return strings.EqualFold(a, b)
+}
+
+func wildcard(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
@@ -0,0 +1,132 @@
+package tree
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/l0k1verloren/skele/pkg/T"
+)
+
+// Snapshot holds the values of every node in a tree at one moment, for test fixtures or rolling back a live
+// reload that failed. Its contents are only meant to be given back to RestoreValues
+type Snapshot struct {
+	values map[string]interface{}
+}
+
+// SnapshotValues captures the current value of every node in the tree, keyed by path. Values are deep copied, so
+// slices, such as IPNetList and Base58, and pointers changed in place afterwards do not change the snapshot
+func SnapshotValues(root T.Cmd) Snapshot {
+	s := Snapshot{make(map[string]interface{})}
+	var walk func(c T.Cmd)
+	walk = func(c T.Cmd) {
+		s.values[c.Path()] = deepCopy(c.Data())
+		for _, child := range c.List() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return s
+}
+
+// RestoreValues sets every node in the tree back to its value in the snapshot. Nodes added since the snapshot are left
+// alone, and nodes that have gone are reported by path after the rest have been restored. Each node gets its own
+// copy, so the snapshot can be restored again
+func RestoreValues(root T.Cmd, s Snapshot) error {
+	seen := make(map[string]bool, len(s.values))
+	var walk func(c T.Cmd)
+	walk = func(c T.Cmd) {
+		if v, ok := s.values[c.Path()]; ok {
+			c.DATA(deepCopy(v))
+			seen[c.Path()] = true
+		}
+		for _, child := range c.List() {
+			walk(child)
+		}
+	}
+	walk(root)
+	var missing []string
+	for p := range s.values {
+		if !seen[p] {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return errors.New("snapshot has values for nodes no longer in the tree: " + strings.Join(missing, ", "))
+}
+
+// deepCopy copies v along with everything it points to, so the copy shares no slices, maps or pointers with it.
+// Values reached more than once, including through cycles, are copied once and the copy shared the same way.
+// Unexported struct fields, such as the location in a time.Time, are copied shallowly
+func deepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return copyValue(reflect.ValueOf(v), make(map[copied]reflect.Value)).Interface()
+}
+
+// copied identifies a pointer, map or slice that has already been copied
+type copied struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+func copyValue(v reflect.Value, seen map[copied]reflect.Value) reflect.Value {
+	out := reflect.New(v.Type()).Elem()
+	var key copied
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return out
+		}
+		key = copied{v.Pointer(), 0, v.Type()}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
+		}
+		if c, ok := seen[key]; ok {
+			return c
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		out.Set(p)
+		seen[key] = out
+		p.Elem().Set(copyValue(v.Elem(), seen))
+	case reflect.Slice:
+		out.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		seen[key] = out
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(copyValue(v.Index(i), seen))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(copyValue(v.Index(i), seen))
+		}
+	case reflect.Map:
+		out.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		seen[key] = out
+		for _, k := range v.MapKeys() {
+			out.SetMapIndex(copyValue(k, seen), copyValue(v.MapIndex(k), seen))
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			out.Set(copyValue(v.Elem(), seen))
+		}
+	case reflect.Struct:
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := out.Field(i); f.CanSet() {
+				f.Set(copyValue(v.Field(i), seen))
+			}
+		}
+	default:
+		out.Set(v)
+	}
+	return out
+}
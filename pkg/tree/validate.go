@@ -0,0 +1,53 @@
+package tree
+
+import (
+	"errors"
+
+	"github.com/l0k1verloren/skele/pkg/T"
+	"github.com/l0k1verloren/skele/pkg/fail"
+)
+
+// Reserved are names the framework itself answers to, which no node may use
+var Reserved = []string{"help", "version"}
+
+// Validate checks a command tree for structural problems: empty names, reserved names, children sharing a name,
+// and names that are also used by an ancestor, which the greedy parser would always read as the ancestor.
+// Every problem found is returned together, as a validation error, rather than stopping at the first
+func Validate(root T.Cmd) error {
+	var problems fail.Multi
+	validate(root, nil, &problems)
+	return fail.Validation(problems.Err())
+}
+
+func validate(c T.Cmd, ancestors []T.Cmd, problems *fail.Multi) {
+	name := c.Name()
+	switch {
+	case name == "":
+		problems.Add(errors.New("node at '" + c.Path() + "' has no name"))
+	case isReserved(name):
+		problems.Add(errors.New("'" + c.Path() + "' uses the reserved name '" + name + "'"))
+	}
+	for _, a := range ancestors {
+		if name != "" && a.Name() == name {
+			problems.Add(errors.New("'" + c.Path() + "' has the same name as its ancestor '" + a.Path() + "'"))
+		}
+	}
+	seen := make(map[string]bool)
+	for _, child := range c.List() {
+		n := child.Name()
+		if n != "" && seen[n] {
+			problems.Add(errors.New("'" + c.Path() + "' has more than one child named '" + n + "'"))
+		}
+		seen[n] = true
+		validate(child, append(ancestors, c), problems)
+	}
+}
+
+func isReserved(name string) bool {
+	for _, r := range Reserved {
+		if name == r {
+			return true
+		}
+	}
+	return false
+}
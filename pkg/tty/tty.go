@@ -0,0 +1,18 @@
+package tty
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// IsTerminal returns true if the writer is an *os.File connected to a terminal. Other character devices, such as
+// /dev/null, are not terminals
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return terminal.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,82 @@
+package winargs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Normalize rewrites Windows style /flag:value and /flag=value arguments into the separate keyword and value
+// that skele reads, and a bare /flag into just the keyword. Only arguments whose flag part looks like a keyword,
+// being letters, digits, - and _, are rewritten, so values such as /home/u/.pod pass through unchanged, as do
+// all other arguments. A single segment path such as /tmp looks like a flag, so give it as /datadir:/tmp
+func Normalize(args []string) (out []string) {
+	for _, a := range args {
+		if len(a) < 2 || a[0] != '/' {
+			out = append(out, a)
+			continue
+		}
+		name, value := a[1:], ""
+		i := strings.IndexAny(name, ":=")
+		if i >= 0 {
+			name, value = name[:i], name[i+1:]
+		}
+		if !keyword(name) {
+			out = append(out, a)
+			continue
+		}
+		out = append(out, name)
+		if i >= 0 {
+			out = append(out, value)
+		}
+	}
+	return
+}
+
+// keyword returns true if name can be the name of a command or parameter
+func keyword(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpandPath replaces %VAR% references with the value of the environment variable, %% with a single %, and
+// cleans the result. References to variables that are not set are left as they are, as cmd.exe does. A path that is
+// or expands to empty stays empty rather than becoming the current directory
+func ExpandPath(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(path, '%')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(path[start+1:], '%')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		b.WriteString(path[:start])
+		name := path[start+1 : end]
+		switch v, ok := os.LookupEnv(name); {
+		case name == "":
+			b.WriteByte('%')
+		case ok:
+			b.WriteString(v)
+		default:
+			b.WriteString(path[start : end+1])
+		}
+		path = path[end+1:]
+	}
+	b.WriteString(path)
+	if b.Len() == 0 {
+		return ""
+	}
+	return filepath.Clean(b.String())
+}
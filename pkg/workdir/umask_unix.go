@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package workdir
+
+import "syscall"
+
+func umask(mode int) int {
+	return syscall.Umask(mode)
+}
@@ -0,0 +1,59 @@
+package workdir
+
+import "os"
+
+// Option changes the process environment a handler runs in
+type Option func(*settings)
+
+type settings struct {
+	dir     string
+	umask   int
+	setMask bool
+}
+
+// Chdir makes the handler run with path as the working directory
+func Chdir(path string) Option {
+	return func(s *settings) {
+		s.dir = path
+	}
+}
+
+// Umask makes the handler run with mode as the file creation mask. It has no effect on Windows
+func Umask(mode int) Option {
+	return func(s *settings) {
+		s.umask, s.setMask = mode, true
+	}
+}
+
+// Run applies the options, runs fn and then restores the previous working directory and umask. Without options fn
+// is simply called. Runs nest, as when a handler dispatches a subcommand with its own options: each restores what
+// it found. The working directory and umask belong to the whole process, so Run must not be used with options from
+// more than one goroutine at a time
+func Run(fn func() error, opts ...Option) (err error) {
+	var s settings
+	for _, o := range opts {
+		o(&s)
+	}
+	if s.dir == "" && !s.setMask {
+		return fn()
+	}
+	if s.dir != "" {
+		var prev string
+		if prev, err = os.Getwd(); err != nil {
+			return
+		}
+		if err = os.Chdir(s.dir); err != nil {
+			return
+		}
+		defer func() {
+			if e := os.Chdir(prev); e != nil && err == nil {
+				err = e
+			}
+		}()
+	}
+	if s.setMask {
+		prev := umask(s.umask)
+		defer umask(prev)
+	}
+	return fn()
+}